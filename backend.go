@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🔌 LLM BACKENDS - Swappable Chat-With-Tools Providers
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Every stage talks to an LLMBackend instead of building its own client.
+// All our calls are stateless (system prompt + user prompt + tools), so the
+// interface is deliberately tiny: one request in, tool-call arguments out.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	OPENROUTER_HTTP_REFERER = "https://github.com/booktok-hype-hub"
+	DEFAULT_LOCAL_BASE_URL  = "http://localhost:11434/v1" // Ollama's OpenAI-compatible endpoint
+)
+
+// LLMBackend - Anything that can run a single tool-calling chat completion
+type LLMBackend interface {
+	ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error)
+	Name() string
+}
+
+// ChatRequest - A stateless chat: no message history, just the two prompts
+type ChatRequest struct {
+	Model        string           `json:"model"`
+	Providers    []string         `json:"providers,omitempty"`
+	SystemPrompt string           `json:"system_prompt"`
+	UserPrompt   string           `json:"user_prompt"`
+	Tools        []ToolDefinition `json:"tools"`
+	Temperature  *float32         `json:"temperature,omitempty"` // nil = the server's default
+}
+
+// ToolDefinition - Backend-neutral function tool (JSON schema parameters)
+type ToolDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ChatResponse - The parts of a completion our stages actually read
+type ChatResponse struct {
+	ToolName      string      `json:"tool_name,omitempty"`
+	ToolArguments string      `json:"tool_arguments,omitempty"`
+	Content       string      `json:"content,omitempty"`
+	Usage         *TokenUsage `json:"usage,omitempty"` // nil when the server didn't report usage
+}
+
+// HasToolCall - True when the model answered through a tool
+func (r ChatResponse) HasToolCall() bool {
+	return r.ToolArguments != ""
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🌐 OPENROUTER - Hosted models with provider routing
+// ═══════════════════════════════════════════════════════════════════════════
+
+// OpenRouterBackend - Talks to openrouter.ai with explicit provider ordering
+type OpenRouterBackend struct {
+	client *openrouter.Client
+}
+
+// NewOpenRouterBackend creates a backend for openrouter.ai (title shows up in their dashboard)
+func NewOpenRouterBackend(apiKey, title string) *OpenRouterBackend {
+	return &OpenRouterBackend{
+		client: openrouter.NewClient(
+			apiKey,
+			openrouter.WithXTitle(title),
+			openrouter.WithHTTPReferer(OPENROUTER_HTTP_REFERER),
+		),
+	}
+}
+
+func (b *OpenRouterBackend) Name() string {
+	return "openrouter"
+}
+
+func (b *OpenRouterBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	chatReq := buildChatCompletionRequest(req)
+	chatReq.Provider = &openrouter.ChatProvider{
+		Order:          req.Providers,
+		AllowFallbacks: boolPtr(false),
+	}
+	chatReq.Usage = &openrouter.IncludeUsage{Include: true} // Adds the billed cost to usage
+
+	resp, err := b.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil {
+		return ChatResponse{}, err
+	}
+	return parseChatCompletionResponse(resp), nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏠 LOCAL - Any OpenAI-compatible server (Ollama, llama.cpp, vLLM...)
+// ═══════════════════════════════════════════════════════════════════════════
+
+// LocalBackend - Self-hosted OpenAI-compatible endpoint (no provider routing)
+type LocalBackend struct {
+	client  *openrouter.Client
+	baseURL string
+	model   string // Overrides the stage model when set (local names differ from OpenRouter slugs)
+}
+
+// NewLocalBackend creates a backend for an OpenAI-compatible /v1 endpoint
+func NewLocalBackend(baseURL, apiKey, model string) *LocalBackend {
+	if baseURL == "" {
+		baseURL = DEFAULT_LOCAL_BASE_URL
+	}
+	config := openrouter.DefaultConfig(apiKey)
+	config.BaseURL = strings.TrimRight(baseURL, "/")
+
+	return &LocalBackend{
+		client:  openrouter.NewClientWithConfig(*config),
+		baseURL: config.BaseURL,
+		model:   model,
+	}
+}
+
+func (b *LocalBackend) Name() string {
+	return "local"
+}
+
+func (b *LocalBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	if b.model != "" {
+		req.Model = b.model
+	}
+
+	resp, err := b.client.CreateChatCompletion(ctx, buildChatCompletionRequest(req))
+	if err != nil {
+		return ChatResponse{}, fmt.Errorf("local backend %s: %w", b.baseURL, err)
+	}
+	return parseChatCompletionResponse(resp), nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🛠️ HELPERS - Translation to/from the OpenAI wire format
+// ═══════════════════════════════════════════════════════════════════════════
+
+func buildChatCompletionRequest(req ChatRequest) openrouter.ChatCompletionRequest {
+	tools := make([]openrouter.Tool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		tools = append(tools, openrouter.Tool{
+			Type: openrouter.ToolTypeFunction,
+			Function: &openrouter.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	return openrouter.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openrouter.ChatCompletionMessage{
+			{
+				Role:    openrouter.ChatMessageRoleSystem,
+				Content: openrouter.Content{Text: req.SystemPrompt},
+			},
+			{
+				Role:    openrouter.ChatMessageRoleUser,
+				Content: openrouter.Content{Text: req.UserPrompt},
+			},
+		},
+		Tools:       tools,
+		Temperature: wireTemperature(req.Temperature),
+	}
+}
+
+func parseChatCompletionResponse(resp openrouter.ChatCompletionResponse) ChatResponse {
+	var out ChatResponse
+	if resp.Usage != nil {
+		out.Usage = &TokenUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+			ReportedCostUSD:  resp.Usage.Cost,
+		}
+	}
+	if len(resp.Choices) == 0 {
+		return out
+	}
+
+	msg := resp.Choices[0].Message
+	out.Content = msg.Content.Text
+	if len(msg.ToolCalls) > 0 {
+		out.ToolName = msg.ToolCalls[0].Function.Name
+		out.ToolArguments = msg.ToolCalls[0].Function.Arguments
+	}
+	return out
+}
+
+// wireTemperature - The client drops temperature 0 (omitempty), which means "server default":
+// that is what an unset temperature gets. The smallest positive float keeps an explicit 0 greedy.
+func wireTemperature(t *float32) float32 {
+	switch {
+	case t == nil:
+		return 0
+	case *t == 0:
+		return math.SmallestNonzeroFloat32
+	}
+	return *t
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestLocalBackendSpeaksTheOpenAIWireFormat(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &sent); err != nil {
+			t.Errorf("request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices": [{"message": {"role": "assistant", "tool_calls": [{"id": "1", "type": "function",
+			"function": {"name": "submit_keywords", "arguments": "{\"keywords\": [\"romance audiobooks\"]}"}}]}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}}`)
+	}))
+	defer server.Close()
+
+	backend := NewLocalBackend(server.URL+"/v1/", "", "llama3")
+	resp, err := backend.ChatWithTools(context.Background(), ChatRequest{
+		Model:        "moonshotai/kimi-k2-thinking",
+		Providers:    []string{"google-vertex"},
+		SystemPrompt: "system",
+		UserPrompt:   "user",
+		Tools:        []ToolDefinition{{Name: "submit_keywords", Parameters: json.RawMessage(`{"type": "object"}`)}},
+		Temperature:  float32Ptr(0.7),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent["model"] != "llama3" || sent["provider"] != nil {
+		t.Errorf("model %v, provider %v: want the local model and no provider routing", sent["model"], sent["provider"])
+	}
+	if messages, _ := json.Marshal(sent["messages"]); !strings.Contains(string(messages), `"role":"system"`) || !strings.Contains(string(messages), `"role":"user"`) {
+		t.Errorf("messages = %s", messages)
+	}
+	if resp.ToolName != "submit_keywords" || !resp.HasToolCall() || resp.Usage == nil || resp.Usage.TotalTokens != 17 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestParseChatCompletionResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name, body string
+		want       ChatResponse
+	}{
+		{"no choices", `{"choices": []}`, ChatResponse{}},
+		{"plain text", `{"choices": [{"message": {"role": "assistant", "content": "romance audiobooks"}}]}`, ChatResponse{Content: "romance audiobooks"}},
+		{"first tool call", `{"choices": [{"message": {"role": "assistant", "tool_calls": [
+			{"type": "function", "function": {"name": "submit_search_terms", "arguments": "{}"}},
+			{"type": "function", "function": {"name": "other", "arguments": "[]"}}]}}]}`,
+			ChatResponse{ToolName: "submit_search_terms", ToolArguments: "{}"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp openrouter.ChatCompletionResponse
+			if err := json.Unmarshal([]byte(tc.body), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if got := parseChatCompletionResponse(resp); got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📦 BATCH MODE - Many Themes, Bounded Workers, Shared Rate Limit
+// ═══════════════════════════════════════════════════════════════════════════
+//
+//   nx-lander-agent batch --input themes.csv --workers 4 --rate 2 --output out/
+//
+// - Input: CSV (column "theme"/"idea", else first column) or JSONL ({"theme": ...})
+// - Every worker shares ONE rate-limited backend (requests/second across the batch)
+// - A failing theme is recorded in the summary; the rest of the batch keeps going
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	DEFAULT_BATCH_WORKERS = 4
+	DEFAULT_BATCH_RATE    = 1.0 // LLM requests per second, shared by all workers
+	BATCH_RESULT_FILENAME = "result.json"
+	BATCH_SUMMARY_FILE    = "summary.json"
+	BATCH_SUMMARY_CSV     = "summary.csv"
+)
+
+// BatchThemeResult - Outcome for one theme (written to <output>/<slug>/result.json)
+type BatchThemeResult struct {
+	PipelineResult
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	ResultPath string `json:"result_path,omitempty"`
+}
+
+// BatchSummary - The report written to <output>/summary.json
+type BatchSummary struct {
+	Input      string             `json:"input"`
+	Total      int                `json:"total"`
+	Succeeded  int                `json:"succeeded"`
+	Failed     int                `json:"failed"`
+	DurationMs int64              `json:"duration_ms"`
+	Usage      UsageReport        `json:"usage"` // Summed over every theme, failed ones included
+	Results    []BatchThemeResult `json:"results"`
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📥 INPUT
+// ═══════════════════════════════════════════════════════════════════════════
+
+// readBatchThemes - Dispatches on file extension (.csv or .jsonl/.ndjson)
+func readBatchThemes(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch input: %w", err)
+	}
+	defer f.Close()
+
+	var themes []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		themes, err = readCSVThemes(f)
+	case ".jsonl", ".ndjson":
+		themes, err = readJSONLThemes(f)
+	default:
+		return nil, fmt.Errorf("unsupported batch input %s (want .csv or .jsonl)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(themes) == 0 {
+		return nil, fmt.Errorf("no themes found in %s", path)
+	}
+	return themes, nil
+}
+
+func readCSVThemes(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	// Header row is optional: use the "theme"/"idea" column when present
+	column := 0
+	for i, cell := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if name == "theme" || name == "idea" {
+			column = i
+			rows = rows[1:]
+			break
+		}
+	}
+
+	var themes []string
+	for _, row := range rows {
+		if column < len(row) {
+			if theme := strings.TrimSpace(row[column]); theme != "" {
+				themes = append(themes, theme)
+			}
+		}
+	}
+	return themes, nil
+}
+
+func readJSONLThemes(r io.Reader) ([]string, error) {
+	decoder := json.NewDecoder(r)
+	var themes []string
+	for line := 1; ; line++ {
+		var record struct {
+			Theme string `json:"theme"`
+			Idea  string `json:"idea"`
+		}
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", line, err)
+		}
+
+		theme := strings.TrimSpace(record.Theme)
+		if theme == "" {
+			theme = strings.TrimSpace(record.Idea)
+		}
+		if theme == "" {
+			return nil, fmt.Errorf("record %d: missing \"theme\"", line)
+		}
+		themes = append(themes, theme)
+	}
+	return themes, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⚙️ WORKER POOL
+// ═══════════════════════════════════════════════════════════════════════════
+
+// runBatch - Processes every theme with at most `workers` in flight
+func runBatch(ctx context.Context, backend LLMBackend, themes []string, workers int, perTheme time.Duration, cfg Config) []BatchThemeResult {
+	if workers <= 0 {
+		workers = DEFAULT_BATCH_WORKERS
+	}
+
+	results := make([]BatchThemeResult, len(themes))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runBatchTheme(ctx, backend, themes[i], perTheme, cfg)
+			}
+		}()
+	}
+
+	for i := range themes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runBatchTheme - One theme, isolated: errors (and panics) become part of the result
+func runBatchTheme(ctx context.Context, backend LLMBackend, theme string, perTheme time.Duration, cfg Config) (result BatchThemeResult) {
+	start := time.Now()
+	result.Theme = theme // Kept even if the pipeline panics before filling it in
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+		result.DurationMs = time.Since(start).Milliseconds()
+		if result.Error != "" {
+			log.Printf("❌ [%s] %s", theme, result.Error)
+		} else {
+			log.Printf("✅ [%s] %d keywords, %d search terms", theme, len(result.Keywords), len(result.SearchTerms))
+		}
+	}()
+
+	themeCtx, cancel := context.WithTimeout(ctx, perTheme)
+	defer cancel()
+
+	pipeline, err := runPipeline(themeCtx, backend, theme, cfg)
+	result.PipelineResult = pipeline
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// writeBatchResults - Per-theme result.json files plus summary.json.
+// A theme whose files can't be written fails on its own; the summaries still cover every theme.
+func writeBatchResults(outputDir, input string, results []BatchThemeResult, elapsed time.Duration) (BatchSummary, error) {
+	summary := BatchSummary{
+		Input:      input,
+		Total:      len(results),
+		DurationMs: elapsed.Milliseconds(),
+	}
+
+	usedSlugs := make(map[string]bool)
+	reports := make([]UsageReport, 0, len(results))
+	for i := range results {
+		reports = append(reports, results[i].Usage)
+		writeBatchTheme(filepath.Join(outputDir, uniqueSlug(results[i].Theme, usedSlugs)), &results[i])
+
+		if results[i].Error == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	summary.Results = results
+	summary.Usage = mergeUsageReports(reports)
+
+	if err := writeJSONFile(filepath.Join(outputDir, BATCH_SUMMARY_FILE), summary); err != nil {
+		return summary, err
+	}
+
+	// Spreadsheet export covers the successful themes only
+	var succeeded []PipelineResult
+	for _, r := range results {
+		if r.Error == "" {
+			succeeded = append(succeeded, r.PipelineResult)
+		}
+	}
+	return summary, writeStructuredOutput(OUTPUT_FORMAT_CSV, filepath.Join(outputDir, BATCH_SUMMARY_CSV), succeeded)
+}
+
+// uniqueSlug - The theme's slug, suffixed -2, -3, ... until no earlier theme has it
+// (including a theme that slugifies to "foo-2" on its own)
+func uniqueSlug(theme string, used map[string]bool) string {
+	base := slugify(theme)
+	if base == "" {
+		base = "theme"
+	}
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[slug] = true
+	return slug
+}
+
+// writeBatchTheme - The theme's brief (successful runs only) and result.json; write errors land on the result
+func writeBatchTheme(dir string, result *BatchThemeResult) {
+	if result.Error == "" {
+		briefPath, err := writeContentBrief(newContentBrief(result.PipelineResult, buildLandingPage(result.PipelineResult)), dir)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.BriefPath = briefPath
+	}
+
+	path := filepath.Join(dir, BATCH_RESULT_FILENAME)
+	if err := writeJSONFile(path, result); err != nil {
+		result.Error = strings.TrimPrefix(result.Error+"; "+err.Error(), "; ")
+		return
+	}
+	result.ResultPath = path
+}
+
+func writeJSONFile(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🚦 RATE LIMITING - One token bucket shared by all workers
+// ═══════════════════════════════════════════════════════════════════════════
+
+// RateLimitedBackend - Spaces calls to at most `rate` per second across goroutines
+type RateLimitedBackend struct {
+	inner    LLMBackend
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// NewRateLimitedBackend wraps inner; rate <= 0 disables limiting
+func NewRateLimitedBackend(inner LLMBackend, rate float64) LLMBackend {
+	if inner == nil || rate <= 0 {
+		return inner
+	}
+	return &RateLimitedBackend{
+		inner:    inner,
+		interval: time.Duration(float64(time.Second) / rate),
+	}
+}
+
+func (b *RateLimitedBackend) Name() string {
+	return b.inner.Name()
+}
+
+func (b *RateLimitedBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	if err := b.wait(ctx); err != nil {
+		return ChatResponse{}, err
+	}
+	return b.inner.ChatWithTools(ctx, req)
+}
+
+// wait - Reserves the next slot, then sleeps until it arrives (or ctx ends)
+func (b *RateLimitedBackend) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	b.next = slot.Add(b.interval)
+	b.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadBatchThemes(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		read        func(io.Reader) ([]string, error)
+		want        []string
+		err         string
+	}{
+		{"csv without header", "romance books\nfantasy audiobooks, extra\n", readCSVThemes, []string{"romance books", "fantasy audiobooks"}, ""},
+		{"csv theme column", "id,Theme\n1, romance books \n2,\n3,crime novels\n", readCSVThemes, []string{"romance books", "crime novels"}, ""},
+		{"csv idea column", "idea\nromance books\n", readCSVThemes, []string{"romance books"}, ""},
+		{"csv empty", "", readCSVThemes, nil, ""},
+		{"jsonl theme and idea", `{"theme": "romance books"}` + "\n" + `{"idea": " crime novels "}` + "\n", readJSONLThemes, []string{"romance books", "crime novels"}, ""},
+		{"jsonl missing theme", `{"theme": "romance books"}` + "\n" + `{"title": "x"}` + "\n", readJSONLThemes, nil, `record 2: missing "theme"`},
+		{"jsonl bad json", `{"theme": "romance books"}` + "\n" + `{"theme": ` + "\n", readJSONLThemes, nil, "record 2:"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			themes, err := tc.read(strings.NewReader(tc.input))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(themes, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("themes = %q, want %q", themes, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteBatchResultsSlugsAndWriteErrors(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	var results []BatchThemeResult
+	for _, theme := range []string{"Foo", "foo", "foo 2", "Blocked", "Broken"} {
+		result := runBatchTheme(context.Background(), nil, theme, time.Minute, cfg)
+		results = append(results, result)
+	}
+	results[4].Error = "keyword stage: model unavailable"
+
+	dir := t.TempDir()
+	// A file where the theme's directory should go: its writes fail, the batch goes on
+	if err := os.WriteFile(filepath.Join(dir, "blocked"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := writeBatchResults(dir, "themes.csv", results, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dirs []string
+	for _, r := range summary.Results {
+		dirs = append(dirs, filepath.Base(filepath.Dir(r.ResultPath)))
+	}
+	if want := "foo|foo-2|foo-2-2|.|broken"; strings.Join(dirs, "|") != want {
+		t.Errorf("result directories = %q, want %q", dirs, want)
+	}
+	if summary.Succeeded != 3 || summary.Failed != 2 || summary.Results[3].Error == "" || summary.Results[3].ResultPath != "" {
+		t.Errorf("summary = %d ok / %d failed, blocked theme %+v", summary.Succeeded, summary.Failed, summary.Results[3].Error)
+	}
+	for _, name := range []string{BATCH_SUMMARY_FILE, BATCH_SUMMARY_CSV} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("summary not written: %v", err)
+		}
+	}
+}
+
+func TestRateLimitedBackendSpacesCallsAndStopsOnCancel(t *testing.T) {
+	interval := 20 * time.Millisecond
+	limiter := NewRateLimitedBackend(&scriptedBackend{}, float64(time.Second/interval)).(*RateLimitedBackend)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("3 calls took %s, want at least %s", elapsed, 2*interval)
+	}
+
+	// The next slot is an interval away: a cancelled caller stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+
+	if _, limited := NewRateLimitedBackend(&scriptedBackend{}, 0).(*RateLimitedBackend); limited || NewRateLimitedBackend(nil, 1) != nil {
+		t.Error("rate <= 0 or no backend should disable limiting")
+	}
+}
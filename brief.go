@@ -0,0 +1,401 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📝 CONTENT BRIEF - What to Write Where, for Humans (MD) and Tools (JSON)
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Pure local generation (NO API calls!) from the same LandingPage that
+// index.html is rendered from, so writers no longer turn the printed lists
+// into a brief by hand - and the brief never disagrees with the page:
+//   - title tag and meta description, with length checks
+//   - H1 and the H2 outline (the cluster sections), with the terms to cover
+//   - FAQ questions from the question-pattern terms, with draft answers
+//   - suggested pages: base keywords worth a page of their own, marked
+//     as internal links once that page exists in the output directory
+//   - word-count target, built up section by section
+//
+// Written next to index.html (and per theme in batch mode):
+//   - content_brief.md: for writers
+//   - content_brief.json: the same data, for CMS imports
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	BRIEF_MARKDOWN_FILENAME = "content_brief.md"
+	BRIEF_JSON_FILENAME     = "content_brief.json"
+
+	TITLE_TAG_MIN        = 30
+	TITLE_TAG_MAX        = 60 // Google cuts titles around 600px ≈ 60 characters
+	TITLE_TAG_SUFFIX     = " | Nextory"
+	META_DESCRIPTION_MIN = 120 // Shorter ones tend to get replaced by a page snippet
+
+	LENGTH_OK        = "ok"
+	LENGTH_TOO_SHORT = "too_short"
+	LENGTH_TOO_LONG  = "too_long"
+	LENGTH_TRUNCATED = "truncated" // Was too long; Text is cut to Max
+
+	BRIEF_WORDS_HERO       = 150
+	BRIEF_WORDS_PER_H2     = 200
+	BRIEF_WORDS_PER_TERM   = 40 // Every extra term an H2 block has to cover
+	BRIEF_WORDS_PER_FAQ    = 60
+	BRIEF_WORDS_TOLERANCE  = 0.15 // Min/max around the target
+	BRIEF_INTERNAL_LINKS   = 5
+	BRIEF_WORDS_ROUNDED_TO = 50
+)
+
+// SECTION_TITLES - Markdown heading prefix per section kind
+var SECTION_TITLES = map[string]string{
+	SECTION_HERO: "Hero",
+	SECTION_H2:   "H2",
+	SECTION_FAQ:  "FAQ",
+}
+
+// ContentBrief - The brief document (content_brief.json)
+type ContentBrief struct {
+	Theme           string             `json:"theme"`
+	Locale          string             `json:"locale"`
+	TitleTag        CheckedText        `json:"title_tag"`
+	MetaDescription CheckedText        `json:"meta_description"`
+	H1              string             `json:"h1"`
+	Hero            OutlineItem        `json:"hero"`
+	Outline         []OutlineItem      `json:"outline"` // H2 blocks in page order
+	FAQHeading      string             `json:"faq_heading"`
+	FAQ             []BriefFAQ         `json:"faq"`
+	InternalLinks   []InternalLink     `json:"internal_links"`
+	WordCount       WordCountTarget    `json:"word_count"`
+	Keywords        []string           `json:"keywords"`
+	Sections        []PageSection      `json:"sections"`
+	Clusters        []TermCluster      `json:"clusters"`
+	Terms           []SearchTermRecord `json:"search_terms"`
+}
+
+// CheckedText - A snippet and how its length compares to what search engines show
+type CheckedText struct {
+	Text   string `json:"text"`
+	Length int    `json:"length"` // Characters
+	Min    int    `json:"min"`
+	Max    int    `json:"max"`
+	Status string `json:"status"` // LENGTH_*
+
+	OriginalLength int `json:"original_length,omitempty"` // Before truncation (LENGTH_TRUNCATED)
+}
+
+// OutlineItem - The hero or one H2 block
+type OutlineItem struct {
+	Heading   string   `json:"heading"`
+	Topic     string   `json:"topic,omitempty"`
+	Terms     []string `json:"terms"` // To cover in the block, heading term first
+	WordCount int      `json:"word_count"`
+}
+
+// BriefFAQ - A question-pattern term as it should appear on the page
+type BriefFAQ struct {
+	Question string `json:"question"`
+	Term     string `json:"term"`
+	Answer   string `json:"answer"` // Draft, for the writer to rework
+}
+
+// InternalLink - A base keyword worth a landing page of its own, to link once it exists
+type InternalLink struct {
+	Anchor  string `json:"anchor"`
+	Path    string `json:"path"`    // Page slug on the site, same as the output directories
+	Section string `json:"section"` // Heading of the block holding most of its terms
+	Terms   int    `json:"terms"`   // Search terms on this page containing the keyword's own words
+	Exists  bool   `json:"exists"`  // Its index.html is already in the output directory; otherwise only suggested
+}
+
+// WordCountTarget - Page length, built up from the sections
+type WordCountTarget struct {
+	Target int `json:"target"`
+	Min    int `json:"min"`
+	Max    int `json:"max"`
+	Hero   int `json:"hero"`
+	H2     int `json:"h2"` // All H2 blocks together
+	FAQ    int `json:"faq"`
+}
+
+// newContentBrief - The brief for one pipeline result and the page built from it (buildLandingPage),
+// so the snippets, H1, outline and FAQ are exactly what index.html shows
+func newContentBrief(result PipelineResult, page LandingPage) ContentBrief {
+	brief := ContentBrief{
+		Theme:           result.Theme,
+		Locale:          result.Locale,
+		TitleTag:        page.Title,
+		MetaDescription: page.MetaDescription,
+		H1:              page.H1,
+		Hero:            OutlineItem{Heading: page.H1, Terms: page.HeroTerms, WordCount: BRIEF_WORDS_HERO},
+		FAQHeading:      page.FAQHeading,
+		Keywords:        result.Keywords,
+		Sections:        result.Sections,
+		Clusters:        result.Clusters,
+		Terms:           result.TermRecords,
+	}
+
+	for _, section := range page.Sections {
+		brief.Outline = append(brief.Outline, OutlineItem{
+			Heading:   section.Heading,
+			Topic:     section.Topic,
+			Terms:     section.Terms,
+			WordCount: BRIEF_WORDS_PER_H2 + BRIEF_WORDS_PER_TERM*max(len(section.Terms)-1, 0),
+		})
+	}
+	for _, faq := range page.FAQ {
+		brief.FAQ = append(brief.FAQ, BriefFAQ(faq))
+	}
+
+	brief.InternalLinks = internalLinks(result.Theme, result.Keywords, brief, stopWordSet(localeFor(result.Locale).StopWords))
+	brief.WordCount = wordCountTarget(brief.Outline, len(brief.FAQ))
+	return brief
+}
+
+// titleTag - "<Hero> – <Theme> | Nextory", dropping parts until it fits TITLE_TAG_MAX
+func titleTag(hero, theme string, wording PageCopy, stopWords map[string]bool) CheckedText {
+	candidates := []string{titleCase(hero) + TITLE_TAG_SUFFIX, fmt.Sprintf(wording.Title, titleCase(theme)), titleCase(theme) + TITLE_TAG_SUFFIX}
+	heroWords := themeWordSet(hero, stopWords)
+	for word := range themeWordSet(theme, stopWords) {
+		if !heroWords[word] {
+			candidates = append([]string{titleCase(hero) + " – " + titleCase(theme) + TITLE_TAG_SUFFIX}, candidates...)
+			break
+		}
+	}
+	for _, title := range candidates {
+		if len([]rune(title)) <= TITLE_TAG_MAX {
+			return checkLength(title, TITLE_TAG_MIN, TITLE_TAG_MAX)
+		}
+	}
+	return fitLength(candidates[len(candidates)-1], TITLE_TAG_MIN, TITLE_TAG_MAX)
+}
+
+func checkLength(text string, minLength, maxLength int) CheckedText {
+	checked := CheckedText{Text: text, Length: len([]rune(text)), Min: minLength, Max: maxLength, Status: LENGTH_OK}
+	switch {
+	case checked.Length < minLength:
+		checked.Status = LENGTH_TOO_SHORT
+	case checked.Length > maxLength:
+		checked.Status = LENGTH_TOO_LONG
+	}
+	return checked
+}
+
+// fitLength - checkLength of the text as written; over-long text is cut to maxLength
+// and reported as LENGTH_TRUNCATED with its original length
+func fitLength(text string, minLength, maxLength int) CheckedText {
+	checked := checkLength(text, minLength, maxLength)
+	if checked.Status != LENGTH_TOO_LONG {
+		return checked
+	}
+	truncated := checkLength(truncateText(text, maxLength), minLength, maxLength)
+	truncated.Status, truncated.OriginalLength = LENGTH_TRUNCATED, checked.Length
+	return truncated
+}
+
+// internalLinks - Base keywords other than the theme, suggested as pages of their own.
+// A keyword counts the page's search terms containing all of its words beyond the theme's,
+// and is placed in the block covering most of them.
+func internalLinks(theme string, keywords []string, brief ContentBrief, stopWords map[string]bool) []InternalLink {
+	blocks := append([]OutlineItem{brief.Hero}, brief.Outline...)
+	faq := OutlineItem{Heading: brief.FAQHeading}
+	for _, item := range brief.FAQ {
+		faq.Terms = append(faq.Terms, item.Term)
+	}
+	blocks = append(blocks, faq)
+
+	themeWords := themeWordSet(theme, stopWords)
+	var links []InternalLink
+	for _, keyword := range keywords {
+		var own []string
+		for word := range themeWordSet(keyword, stopWords) {
+			if !themeWords[word] {
+				own = append(own, word)
+			}
+		}
+		if len(own) == 0 {
+			continue // This page
+		}
+
+		link := InternalLink{Anchor: keyword, Path: "/" + slugify(keyword) + "/"}
+		placed := make(map[string]int)
+		var order []string
+		for _, block := range blocks {
+			for _, term := range block.Terms {
+				if !containsAllWords(term, own, stopWords) {
+					continue
+				}
+				link.Terms++
+				if placed[block.Heading] == 0 {
+					order = append(order, block.Heading)
+				}
+				placed[block.Heading]++
+			}
+		}
+		if link.Terms > 0 {
+			link.Section = mostCommon(placed, order)
+			links = append(links, link)
+		}
+	}
+
+	sort.SliceStable(links, func(a, b int) bool { return links[a].Terms > links[b].Terms })
+	return firstN(links, BRIEF_INTERNAL_LINKS)
+}
+
+// containsAllWords - Every word (as themeWordSet normalises it) appears in the term
+func containsAllWords(term string, words []string, stopWords map[string]bool) bool {
+	termWords := themeWordSet(term, stopWords)
+	for _, word := range words {
+		if !termWords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// markExistingPages - Flags the links whose page has already been rendered under siteDir
+func markExistingPages(links []InternalLink, siteDir string) {
+	for i := range links {
+		_, err := os.Stat(filepath.Join(siteDir, strings.Trim(links[i].Path, "/"), LANDING_PAGE_FILENAME))
+		links[i].Exists = err == nil
+	}
+}
+
+// wordCountTarget - Hero + H2 blocks + FAQ answers, rounded to BRIEF_WORDS_ROUNDED_TO
+func wordCountTarget(outline []OutlineItem, faqs int) WordCountTarget {
+	target := WordCountTarget{Hero: BRIEF_WORDS_HERO, FAQ: BRIEF_WORDS_PER_FAQ * faqs}
+	for _, item := range outline {
+		target.H2 += item.WordCount
+	}
+	round := func(words float64) int {
+		return int(math.Round(words/BRIEF_WORDS_ROUNDED_TO)) * BRIEF_WORDS_ROUNDED_TO
+	}
+	total := float64(target.Hero + target.H2 + target.FAQ)
+	target.Target = round(total)
+	target.Min = round(total * (1 - BRIEF_WORDS_TOLERANCE))
+	target.Max = round(total * (1 + BRIEF_WORDS_TOLERANCE))
+	return target
+}
+
+func firstN[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+// writeContentBrief - Writes both brief files to outputDir and returns the Markdown path.
+// Link targets are looked up next to outputDir, where their pages would be rendered.
+func writeContentBrief(brief ContentBrief, outputDir string) (string, error) {
+	markExistingPages(brief.InternalLinks, filepath.Dir(outputDir))
+	if err := writeJSONFile(filepath.Join(outputDir, BRIEF_JSON_FILENAME), brief); err != nil {
+		return "", err
+	}
+	path := filepath.Join(outputDir, BRIEF_MARKDOWN_FILENAME)
+	if err := os.WriteFile(path, []byte(renderBriefMarkdown(brief)), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🖋️ MARKDOWN
+// ═══════════════════════════════════════════════════════════════════════════
+
+// renderBriefMarkdown - SEO snippets, then the page section by section, links and clusters
+func renderBriefMarkdown(brief ContentBrief) string {
+	records := make(map[string]SearchTermRecord)
+	for _, r := range brief.Terms {
+		records[r.Term] = r
+	}
+	primaries := make(map[string]bool)
+	for _, c := range brief.Clusters {
+		primaries[c.Primary] = true
+	}
+	termLine := func(term string) string {
+		line := fmt.Sprintf("- %s", term)
+		if r, ok := records[term]; ok {
+			line += fmt.Sprintf(" (%s · %s)", r.Intent, r.Format)
+		}
+		if primaries[term] {
+			line += " **primary**"
+		}
+		return line + "\n"
+	}
+
+	var b strings.Builder
+	wc := brief.WordCount
+	fmt.Fprintf(&b, "# Content brief: %s\n\n", titleCase(brief.Theme))
+	fmt.Fprintf(&b, "Locale: %s · %d search terms in %d clusters · ~%d words (%d–%d)\n\n",
+		brief.Locale, len(brief.Terms), len(brief.Clusters), wc.Target, wc.Min, wc.Max)
+	if len(brief.Keywords) > 0 {
+		fmt.Fprintf(&b, "Keywords: %s\n\n", strings.Join(brief.Keywords, ", "))
+	}
+
+	b.WriteString("## SEO\n\n")
+	for _, snippet := range []struct {
+		name string
+		text CheckedText
+	}{{"Title tag", brief.TitleTag}, {"Meta description", brief.MetaDescription}} {
+		status := strings.ReplaceAll(snippet.text.Status, "_", " ")
+		if snippet.text.OriginalLength > 0 {
+			status += fmt.Sprintf(" from %d", snippet.text.OriginalLength)
+		}
+		fmt.Fprintf(&b, "- %s (%d/%d characters, %s): %s\n", snippet.name, snippet.text.Length, snippet.text.Max, status, snippet.text.Text)
+	}
+	fmt.Fprintf(&b, "- H1: %s\n", brief.H1)
+
+	b.WriteString("\n## Page structure\n")
+	blocks := append([]OutlineItem{brief.Hero}, brief.Outline...)
+	for i, block := range blocks {
+		kind := SECTION_H2
+		if i == 0 {
+			kind = SECTION_HERO
+		}
+		fmt.Fprintf(&b, "\n### %s: %s (~%d words)\n\n", SECTION_TITLES[kind], block.Heading, block.WordCount)
+		if block.Topic != "" && block.Topic != block.Heading {
+			fmt.Fprintf(&b, "Topic: %s\n\n", block.Topic)
+		}
+		for _, term := range block.Terms {
+			b.WriteString(termLine(term))
+		}
+	}
+	if len(brief.FAQ) > 0 {
+		fmt.Fprintf(&b, "\n### %s: %s (~%d words)\n\n", SECTION_TITLES[SECTION_FAQ], brief.FAQHeading, wc.FAQ)
+		for _, faq := range brief.FAQ {
+			fmt.Fprintf(&b, "- **%s** %s\n", faq.Question, faq.Answer)
+		}
+	}
+
+	var existing, suggested []InternalLink
+	for _, link := range brief.InternalLinks {
+		if link.Exists {
+			existing = append(existing, link)
+		} else {
+			suggested = append(suggested, link)
+		}
+	}
+	if len(existing) > 0 {
+		b.WriteString("\n## Internal links\n\n")
+		for _, link := range existing {
+			fmt.Fprintf(&b, "- [%s](%s) in \"%s\" (search terms: %d)\n", link.Anchor, link.Path, link.Section, link.Terms)
+		}
+	}
+	if len(suggested) > 0 {
+		b.WriteString("\n## Suggested pages\n\nNo page yet - build one, then link it from the block named:\n\n")
+		for _, link := range suggested {
+			fmt.Fprintf(&b, "- %s (%s) from \"%s\" (search terms: %d)\n", link.Anchor, link.Path, link.Section, link.Terms)
+		}
+	}
+
+	b.WriteString("\n## Clusters\n\n| # | Label | Primary | Intent | Terms |\n|---|---|---|---|---|\n")
+	for _, c := range brief.Clusters {
+		fmt.Fprintf(&b, "| %d | %s | %s | %s | %s |\n", c.ID, c.Label, c.Primary, c.Intent, strings.Join(c.Terms, "; "))
+	}
+	return b.String()
+}
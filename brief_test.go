@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func cassetteBriefResult(t *testing.T) PipelineResult {
+	t.Helper()
+	keywords := []string{"romance audiobooks", "romance ebooks", "romance books"}
+	records := buildTermRecords(cassetteRefinedTerms, nil, keywords, defaultPatternSet, defaultStopWordSet)
+	stage, err := runClusterStage(context.Background(), nil, "romance books", records, cassetteTestConfig(t), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return PipelineResult{
+		Theme: "romance books", Locale: "en", Keywords: keywords, SearchTerms: cassetteRefinedTerms,
+		TermRecords: records, Clusters: stage.Clusters, Sections: stage.Sections,
+	}
+}
+
+func TestContentBriefSnippetsOutlineFAQAndLinks(t *testing.T) {
+	brief := newContentBrief(cassetteBriefResult(t), buildLandingPage(cassetteBriefResult(t)))
+
+	if brief.TitleTag.Text != "Best Romance Audiobooks – Romance Books | Nextory" || brief.TitleTag.Status != LENGTH_OK {
+		t.Errorf("title tag = %+v", brief.TitleTag)
+	}
+	if m := brief.MetaDescription; m.Length > META_DESCRIPTION_MAX || m.Status != LENGTH_OK {
+		t.Errorf("meta description = %+v", m)
+	}
+	if brief.H1 != "Best Romance Audiobooks: Listen and Read Without Limits" || len(brief.Outline) != LANDING_PAGE_H2_COUNT {
+		t.Errorf("H1 %q, %d H2 blocks", brief.H1, len(brief.Outline))
+	}
+
+	var questions []string
+	for _, faq := range brief.FAQ {
+		questions = append(questions, faq.Question)
+	}
+	if want := "Where to listen to romance books?|How to find spicy romance ebooks?|Which romance app works offline?"; strings.Join(questions, "|") != want {
+		t.Errorf("FAQ = %q, want the question-pattern terms", questions)
+	}
+
+	// The theme is this page; the other keywords are suggested pages until they are rendered
+	if len(brief.InternalLinks) != 2 || brief.InternalLinks[0].Path != "/romance-audiobooks/" || brief.InternalLinks[0].Exists {
+		t.Errorf("internal links = %+v", brief.InternalLinks)
+	}
+
+	wc := brief.WordCount
+	if wc.Target%BRIEF_WORDS_ROUNDED_TO != 0 || wc.Min >= wc.Target || wc.Max <= wc.Target || wc.FAQ != 3*BRIEF_WORDS_PER_FAQ || wc.Hero != BRIEF_WORDS_HERO {
+		t.Errorf("word count = %+v", wc)
+	}
+}
+
+func TestContentBriefLengthChecksAndQuestionOnlyTerms(t *testing.T) {
+	long := checkLength(strings.Repeat("x", TITLE_TAG_MAX+1), TITLE_TAG_MIN, TITLE_TAG_MAX)
+	short := checkLength("Romance | Nextory", TITLE_TAG_MIN, TITLE_TAG_MAX)
+	if long.Status != LENGTH_TOO_LONG || short.Status != LENGTH_TOO_SHORT {
+		t.Errorf("length checks: %+v, %+v", long, short)
+	}
+
+	// A hero term too long for the title falls back to the theme
+	if title := titleTag("unlimited spicy enemies to lovers romance audiobooks for the commute", "romance books", DEFAULT_PAGE_COPY, defaultStopWordSet); title.Text != "Romance Books – Audiobooks & E-books | Nextory" || title.Status != LENGTH_OK {
+		t.Errorf("fallback title = %+v", title)
+	}
+
+	// Only questions: the theme carries the hero, every question lands in the FAQ
+	terms := []string{"where to listen to romance books", "how to find romance ebooks"}
+	records := buildTermRecords(terms, nil, []string{"romance books"}, defaultPatternSet, defaultStopWordSet)
+	clusters, _ := clusterTerms(context.Background(), "romance books", records, defaultClusterConfig(), nil)
+	result := PipelineResult{Theme: "romance books", SearchTerms: terms, TermRecords: records, Clusters: clusters, Sections: mapClustersToSections("romance books", clusters, records, DEFAULT_PAGE_COPY)}
+	brief := newContentBrief(result, buildLandingPage(result))
+	if brief.H1 != "Romance Books: Listen and Read Without Limits" || len(brief.Outline) != 0 || len(brief.FAQ) != 2 || len(brief.InternalLinks) != 0 {
+		t.Errorf("question-only brief = %+v", brief)
+	}
+	if md := renderBriefMarkdown(brief); !strings.Contains(md, "### FAQ: Frequently asked questions (~120 words)") || !strings.Contains(md, "**How to find romance ebooks?**") {
+		t.Errorf("question-only markdown:\n%s", md)
+	}
+}
+
+func TestOverLengthSnippetsAreTruncated(t *testing.T) {
+	fitted := fitLength(strings.Repeat("word ", 40), META_DESCRIPTION_MIN, META_DESCRIPTION_MAX)
+	if fitted.Status != LENGTH_TRUNCATED || fitted.OriginalLength != 200 || fitted.Length > META_DESCRIPTION_MAX {
+		t.Errorf("fitLength = %+v", fitted)
+	}
+
+	theme := "unabridged historical regency era enemies to lovers romance audiobooks"
+	if title := titleTag(theme, theme, DEFAULT_PAGE_COPY, defaultStopWordSet); title.Status != LENGTH_TRUNCATED || title.Length > TITLE_TAG_MAX || title.OriginalLength <= TITLE_TAG_MAX {
+		t.Errorf("long theme title = %+v", title)
+	}
+
+	page := buildLandingPage(PipelineResult{Theme: "romance books", SearchTerms: []string{strings.Repeat("very long romance audiobook search term ", 4)}})
+	if m := page.MetaDescription; m.Status != LENGTH_TRUNCATED || m.Length > META_DESCRIPTION_MAX {
+		t.Errorf("long hero meta description = %+v", m)
+	}
+}
+
+func TestBriefMatchesRenderedPageAndLabelsSuggestedLinks(t *testing.T) {
+	result := cassetteBriefResult(t)
+	site := t.TempDir()
+	dir := filepath.Join(site, "romance-books")
+	page := buildLandingPage(result)
+	if _, err := renderLandingPage(page, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(site, "romance-audiobooks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(site, "romance-audiobooks", LANDING_PAGE_FILENAME), []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	brief := newContentBrief(result, page)
+	path, err := writeContentBrief(brief, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !brief.InternalLinks[0].Exists || brief.InternalLinks[1].Exists {
+		t.Errorf("links = %+v, want only /romance-audiobooks/ to exist", brief.InternalLinks)
+	}
+	markdown, _ := os.ReadFile(path)
+	for _, want := range []string{"## Internal links", "## Suggested pages"} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("brief lacks %q:\n%s", want, markdown)
+		}
+	}
+
+	rendered, _ := os.ReadFile(filepath.Join(dir, LANDING_PAGE_FILENAME))
+	wants := []string{"<title>" + html.EscapeString(brief.TitleTag.Text) + "</title>", "<h1>" + html.EscapeString(brief.H1) + "</h1>"}
+	for _, item := range brief.Outline {
+		wants = append(wants, "<h2>"+html.EscapeString(item.Heading)+"</h2>")
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(rendered), want) {
+			t.Errorf("index.html lacks the brief's %q", want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧾 BUDGETS - Hard Caps on Tokens, Dollars and Wall-Clock per Run
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// One Budget is created per run (per theme in batch mode) and shared by
+// every stage. It reads spend from the run's UsageTracker, so whatever the
+// metered backend recorded is what counts.
+//
+// A call is refused when it WOULD exceed a cap: the next call is estimated
+// as the average of the calls so far. The SearchTermAgent treats that as a
+// stopping condition (best-so-far terms, status "budget_exhausted"), not an error.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// BudgetLimits - [budget]; 0 = no cap for that dimension
+type BudgetLimits struct {
+	MaxTokens     int     `json:"max_tokens"`
+	MaxCostUSD    float64 `json:"max_cost_usd"`
+	MaxDurationMs int     `json:"max_duration_ms"`
+}
+
+// Unlimited - True when no cap is set at all
+func (l BudgetLimits) Unlimited() bool {
+	return l.MaxTokens == 0 && l.MaxCostUSD == 0 && l.MaxDurationMs == 0
+}
+
+// ErrBudgetExhausted - Wrapped by every BudgetExhaustedError (errors.Is works)
+var ErrBudgetExhausted = errors.New("budget exhausted")
+
+// BudgetExhaustedError - Which cap stopped the run and where it stood
+type BudgetExhaustedError struct {
+	Reason string
+}
+
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("budget exhausted: %s", e.Reason)
+}
+
+func (e *BudgetExhaustedError) Unwrap() error {
+	return ErrBudgetExhausted
+}
+
+// Budget - Caps plus the live usage they are checked against
+type Budget struct {
+	limits BudgetLimits
+	usage  *UsageTracker
+	start  time.Time
+}
+
+// NewBudget starts the clock now; a nil tracker only enforces the time cap
+func NewBudget(limits BudgetLimits, usage *UsageTracker) *Budget {
+	return &Budget{limits: limits, usage: usage, start: time.Now()}
+}
+
+// Elapsed - Wall-clock since the budget was created
+func (b *Budget) Elapsed() time.Duration {
+	return time.Since(b.start)
+}
+
+// CheckNextCall - nil when one more call fits under every cap, else a *BudgetExhaustedError.
+// A nil budget never refuses.
+func (b *Budget) CheckNextCall() error {
+	if b == nil || b.limits.Unlimited() {
+		return nil
+	}
+
+	var spent StageUsage
+	if b.usage != nil {
+		spent = b.usage.Report().Total
+	}
+
+	// Expected cost of the next call: the average so far (nothing known before the first)
+	var nextTokens int
+	var nextCost float64
+	if spent.Calls > 0 {
+		nextTokens = spent.TotalTokens / spent.Calls
+		nextCost = spent.CostUSD / float64(spent.Calls)
+	}
+
+	var reasons []string
+	if max := b.limits.MaxTokens; max > 0 && spent.TotalTokens+nextTokens > max {
+		reasons = append(reasons, fmt.Sprintf("tokens %d used + ~%d next > %d", spent.TotalTokens, nextTokens, max))
+	}
+	if max := b.limits.MaxCostUSD; max > 0 && spent.CostUSD+nextCost > max {
+		reasons = append(reasons, fmt.Sprintf("cost %s spent + ~%s next > %s", formatUSD(spent.CostUSD), formatUSD(nextCost), formatUSD(max)))
+	}
+	if max := time.Duration(b.limits.MaxDurationMs) * time.Millisecond; max > 0 && b.Elapsed() >= max {
+		reasons = append(reasons, fmt.Sprintf("elapsed %s >= %s", b.Elapsed().Round(time.Millisecond), max))
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+	return &BudgetExhaustedError{Reason: strings.Join(reasons, "; ")}
+}
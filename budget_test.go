@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBudgetStopsRefinementWithBestSoFar(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.Budget.MaxTokens = 1000 // Initial call spends 550; a second one would reach ~1100
+
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: cassetteInitialTerms},
+		{terms: cassetteRefinedTerms},
+	}}
+	usage := NewUsageTracker(cfg.registry)
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, usage, NewBudget(cfg.Budget, usage))
+	if err != nil {
+		t.Fatalf("budget exhaustion must not be an error: %v", err)
+	}
+
+	if result.Status != SEARCH_TERM_STATUS_BUDGET_EXHAUSTED {
+		t.Errorf("status = %q, want %q", result.Status, SEARCH_TERM_STATUS_BUDGET_EXHAUSTED)
+	}
+	if !strings.Contains(result.StopReason, "tokens") {
+		t.Errorf("stop reason = %q, want it to name the token cap", result.StopReason)
+	}
+	if result.APICalls != 1 || strings.Join(result.Terms, "|") != strings.Join(cassetteInitialTerms, "|") {
+		t.Errorf("got %d calls and %q, want the initial terms after 1 call", result.APICalls, result.Terms)
+	}
+}
+
+func TestBudgetCaps(t *testing.T) {
+	usage := NewUsageTracker(nil)
+	usage.Record("keywords", "m", TokenUsage{PromptTokens: 100, CompletionTokens: 100, TotalTokens: 200, ReportedCostUSD: 0.02})
+
+	cases := []struct {
+		name   string
+		limits BudgetLimits
+		want   string // "" = call allowed
+	}{
+		{"unlimited", BudgetLimits{}, ""},
+		{"tokens fit", BudgetLimits{MaxTokens: 400}, ""},
+		{"tokens would exceed", BudgetLimits{MaxTokens: 399}, "tokens"},
+		{"cost fits", BudgetLimits{MaxCostUSD: 0.05}, ""},
+		{"cost would exceed", BudgetLimits{MaxCostUSD: 0.03}, "cost"},
+	}
+	for _, tc := range cases {
+		err := NewBudget(tc.limits, usage).CheckNextCall()
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%s: unexpected %v", tc.name, err)
+		case tc.want != "" && (err == nil || !errors.Is(err, ErrBudgetExhausted) || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%s: err = %v, want budget exhausted on %s", tc.name, err, tc.want)
+		}
+	}
+
+	budget := NewBudget(BudgetLimits{MaxDurationMs: 1}, nil)
+	time.Sleep(2 * time.Millisecond)
+	if err := budget.CheckNextCall(); err == nil || !strings.Contains(err.Error(), "elapsed") {
+		t.Errorf("time cap: err = %v, want elapsed", err)
+	}
+
+	var none *Budget
+	if err := none.CheckNextCall(); err != nil {
+		t.Errorf("nil budget refused a call: %v", err)
+	}
+}
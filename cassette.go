@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📼 CASSETTES - Record/Replay for LLM Calls
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Record mode wraps a real backend and writes every request/response pair
+// to a JSON cassette. Replay mode serves them back by request hash, so the
+// refinement loop can be reproduced byte-for-byte offline.
+//
+// Identical requests are replayed in the order they were recorded.
+// Errors keep their HTTP status and retry classification, so a recorded
+// 429-then-success replays through RetryingBackend exactly as it ran live.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	CASSETTE_MODE_RECORD = "record"
+	CASSETTE_MODE_REPLAY = "replay"
+	CASSETTE_VERSION     = 1
+)
+
+// Cassette - On-disk format
+type Cassette struct {
+	Version      int                   `json:"version"`
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+// CassetteInteraction - One recorded chat call
+type CassetteInteraction struct {
+	Hash     string       `json:"hash"`
+	Request  ChatRequest  `json:"request"`
+	Response ChatResponse `json:"response"`
+	Error    string       `json:"error,omitempty"`
+
+	// Only set with Error: how RetryingBackend classified it during recording
+	Status    int  `json:"status,omitempty"` // HTTP status, 0 for transport errors
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// hashChatRequest - Stable identity for a request (model, prompts, tools, temperature)
+func hashChatRequest(req ChatRequest) string {
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⏺️ RECORD
+// ═══════════════════════════════════════════════════════════════════════════
+
+// RecordingBackend - Passes calls through and appends them to the cassette file
+type RecordingBackend struct {
+	inner    LLMBackend
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingBackend creates a recorder that (re)writes path after every call
+func NewRecordingBackend(inner LLMBackend, path string) *RecordingBackend {
+	return &RecordingBackend{
+		inner:    inner,
+		path:     path,
+		cassette: Cassette{Version: CASSETTE_VERSION},
+	}
+}
+
+func (b *RecordingBackend) Name() string {
+	return b.inner.Name() + "+record"
+}
+
+func (b *RecordingBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := b.inner.ChatWithTools(ctx, req)
+
+	interaction := CassetteInteraction{
+		Hash:     hashChatRequest(req),
+		Request:  req,
+		Response: resp,
+	}
+	if err != nil {
+		interaction.Error = err.Error()
+		interaction.Status = httpStatusOf(err)
+		interaction.Retryable = isRetryableError(err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cassette.Interactions = append(b.cassette.Interactions, interaction)
+
+	// Save eagerly so a crashed or cancelled run still leaves a usable cassette
+	if saveErr := b.save(); saveErr != nil {
+		log.Printf("⚠️  Failed to write cassette %s: %v", b.path, saveErr)
+	}
+
+	return resp, err
+}
+
+func (b *RecordingBackend) save() error {
+	data, err := json.MarshalIndent(b.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, data, 0o644)
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ▶️ REPLAY
+// ═══════════════════════════════════════════════════════════════════════════
+
+// ReplayBackend - Serves recorded responses, never touches the network
+type ReplayBackend struct {
+	path    string
+	mu      sync.Mutex
+	pending map[string][]CassetteInteraction // FIFO per request hash
+}
+
+// NewReplayBackend loads a cassette recorded by RecordingBackend
+func NewReplayBackend(path string) (*ReplayBackend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if cassette.Version != CASSETTE_VERSION {
+		return nil, fmt.Errorf("cassette %s has version %d, expected %d", path, cassette.Version, CASSETTE_VERSION)
+	}
+
+	pending := make(map[string][]CassetteInteraction)
+	for _, interaction := range cassette.Interactions {
+		pending[interaction.Hash] = append(pending[interaction.Hash], interaction)
+	}
+
+	log.Printf("📼 Loaded %d recorded interactions from %s", len(cassette.Interactions), path)
+	return &ReplayBackend{path: path, pending: pending}, nil
+}
+
+func (b *ReplayBackend) Name() string {
+	return "replay"
+}
+
+func (b *ReplayBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	hash := hashChatRequest(req)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	queue := b.pending[hash]
+	if len(queue) == 0 {
+		return ChatResponse{}, fmt.Errorf("cassette %s has no recorded response for request %s (model %s)", b.path, hash[:12], req.Model)
+	}
+	interaction := queue[0]
+	b.pending[hash] = queue[1:]
+
+	if interaction.Error != "" {
+		return interaction.Response, &replayedError{
+			message:   interaction.Error,
+			status:    interaction.Status,
+			retryable: interaction.Retryable,
+		}
+	}
+	return interaction.Response, nil
+}
+
+// replayedError - A recorded failure: same message, same retry classification
+type replayedError struct {
+	message   string
+	status    int
+	retryable bool
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Retryable() bool {
+	return e.retryable
+}
+
+func (e *replayedError) HTTPStatus() int {
+	return e.status
+}
+
+// applyCassetteMode - Wraps (record) or replaces (replay) the backend per CLI flags
+func applyCassetteMode(backend LLMBackend, mode, path string) (LLMBackend, error) {
+	switch mode {
+	case "":
+		return backend, nil
+	case CASSETTE_MODE_RECORD:
+		if path == "" {
+			return nil, fmt.Errorf("cassette mode %q requires a cassette path", mode)
+		}
+		if backend == nil {
+			return nil, fmt.Errorf("cannot record a cassette without a backend (set OPENROUTER_API_KEY or LOCAL_LLM_BASE_URL)")
+		}
+		return NewRecordingBackend(backend, path), nil
+	case CASSETTE_MODE_REPLAY:
+		if path == "" {
+			return nil, fmt.Errorf("cassette mode %q requires a cassette path", mode)
+		}
+		return NewReplayBackend(path)
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q (want %q or %q)", mode, CASSETTE_MODE_RECORD, CASSETTE_MODE_REPLAY)
+	}
+}
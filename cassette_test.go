@@ -0,0 +1,160 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// go test -run TestReplaySearchTermAgent -update re-records the fixture after prompt changes
+var updateCassettes = flag.Bool("update", false, "re-record testdata cassettes from the scripted backend")
+
+const SEARCH_TERMS_CASSETTE = "testdata/search_terms_refinement.cassette.json"
+
+var (
+	// Initial answer: no comparisons, questions, lists or value terms, so one refinement is needed
+	cassetteInitialTerms = []string{
+		"romance audiobooks narrated", "romance ebooks 2025", "romance audiobooks library",
+		"romance ebook deals", "romance audiobooks for commute", "romance books for beginners",
+		"popular romance series", "trending romance novels", "romance magazines subscription",
+		"enemies to lovers audiobooks", "slow burn romance ebooks", "romance audiobooks for family",
+		"historical romance listening", "contemporary romance reads", "romantasy audiobook picks",
+	}
+	cassetteRefinedTerms = []string{
+		"best romance audiobooks", "top romance ebooks 2025", "unlimited romance audiobooks",
+		"free romance ebook trial", "romance audiobooks for commute", "where to listen to romance books",
+		"how to find spicy romance ebooks", "romance audiobooks vs ebooks", "kindle unlimited alternative for romance",
+		"enemies to lovers audiobooks", "slow burn romance series", "historical romance narrators",
+		"cozy small town romance", "romantasy audiobook picks", "which romance app works offline",
+	}
+)
+
+// scriptedBackend - Plays a fixed list of outcomes in order (stands in for OpenRouter when recording)
+type scriptedBackend struct {
+	steps    []scriptedStep
+	requests []ChatRequest // Every request seen, in order
+}
+
+type scriptedStep struct {
+	terms []string
+	field string // Tool argument holding terms; "" = search_terms
+	err   error
+}
+
+func (b *scriptedBackend) Name() string {
+	return "scripted"
+}
+
+func (b *scriptedBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	if len(b.steps) == 0 {
+		return ChatResponse{}, fmt.Errorf("scripted backend exhausted")
+	}
+	step := b.steps[0]
+	b.steps = b.steps[1:]
+	b.requests = append(b.requests, req)
+	if step.err != nil {
+		return ChatResponse{}, step.err
+	}
+	args, _ := json.Marshal(map[string][]string{cmp.Or(step.field, "search_terms"): step.terms})
+	return ChatResponse{
+		ToolName:      "submit_search_terms",
+		ToolArguments: string(args),
+		Usage:         &TokenUsage{PromptTokens: 400, CompletionTokens: 150, TotalTokens: 550},
+	}, nil
+}
+
+func cassetteTestConfig(t *testing.T) Config {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.Retry.InitialBackoffMs = 0
+	cfg.Retry.MaxBackoffMs = 0
+	if err := cfg.resolveModels(); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestReplaySearchTermAgent(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	keywords := []string{"romance audiobooks", "romance ebooks", "romance books"}
+
+	if *updateCassettes {
+		recorder := NewRecordingBackend(&scriptedBackend{steps: []scriptedStep{
+			{err: &openrouter.APIError{Message: "rate limited", HTTPStatusCode: http.StatusTooManyRequests}},
+			{terms: cassetteInitialTerms},
+			{terms: cassetteRefinedTerms},
+		}}, SEARCH_TERMS_CASSETTE)
+		if _, err := runSearchTermStage(context.Background(), recorder, "romance books", keywords, cfg, nil, nil); err != nil {
+			t.Fatalf("recording failed: %v", err)
+		}
+	}
+
+	replay, err := NewReplayBackend(SEARCH_TERMS_CASSETTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage := NewUsageTracker(cfg.registry)
+	result, err := runSearchTermStage(context.Background(), replay, "romance books", keywords, cfg, usage, nil)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	if strings.Join(result.Terms, "|") != strings.Join(cassetteRefinedTerms, "|") {
+		t.Errorf("terms = %q, want the refined set", result.Terms)
+	}
+	if result.Iterations != 1 {
+		t.Errorf("iterations = %d, want 1", result.Iterations)
+	}
+	if result.APICalls != 2 {
+		t.Errorf("api calls = %d, want 2", result.APICalls)
+	}
+	if total := usage.Report().Total; total.Calls != 2 || total.TotalTokens != 1100 {
+		t.Errorf("usage = %d calls / %d tokens, want 2 / 1100", total.Calls, total.TotalTokens)
+	}
+}
+
+func TestReplayedErrorsKeepRetryClassification(t *testing.T) {
+	cases := []struct {
+		err       error
+		status    int
+		retryable bool
+	}{
+		{&openrouter.APIError{Message: "slow down", HTTPStatusCode: http.StatusTooManyRequests}, 429, true},
+		{&openrouter.APIError{Message: "upstream", HTTPStatusCode: http.StatusBadGateway}, 502, true},
+		{&openrouter.APIError{Message: "bad key", HTTPStatusCode: http.StatusUnauthorized}, 401, false},
+		{context.DeadlineExceeded, 0, true},
+		{fmt.Errorf("no tool call in response"), 0, false},
+	}
+
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "cassette.json")
+		recorder := NewRecordingBackend(&scriptedBackend{steps: []scriptedStep{{err: tc.err}}}, path)
+		req := ChatRequest{Model: "m", UserPrompt: tc.err.Error()}
+		if _, err := recorder.ChatWithTools(context.Background(), req); err == nil {
+			t.Fatalf("%v: recorder swallowed the error", tc.err)
+		}
+
+		replay, err := NewReplayBackend(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = replay.ChatWithTools(context.Background(), req)
+		if err == nil || err.Error() != tc.err.Error() {
+			t.Errorf("replayed error = %v, want %q", err, tc.err.Error())
+		}
+		if got := isRetryableError(err); got != tc.retryable {
+			t.Errorf("%v: retryable = %v, want %v", tc.err, got, tc.retryable)
+		}
+		if got := httpStatusOf(err); got != tc.status {
+			t.Errorf("%v: status = %d, want %d", tc.err, got, tc.status)
+		}
+	}
+}
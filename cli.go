@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⌨️ CLI - Subcommands and Flags
+// ═══════════════════════════════════════════════════════════════════════════
+//
+//   nx-lander-agent run          --idea "romance books" --output out/
+//   nx-lander-agent keywords     --idea "romance books" --count 10
+//   nx-lander-agent search-terms --idea "romance books" --keywords "a,b,c"
+//   nx-lander-agent brief        --idea "romance books" --output out/
+//   nx-lander-agent batch        --input themes.csv --workers 4 --rate 2
+//   nx-lander-agent markets      --idea "romance books" --markets SE,FI,DE,NL
+//
+// No subcommand = run. No --idea = interactive prompt (the original UX).
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	CMD_RUN          = "run"
+	CMD_KEYWORDS     = "keywords"
+	CMD_SEARCH_TERMS = "search-terms"
+	CMD_BRIEF        = "brief"
+	CMD_BATCH        = "batch"
+	CMD_MARKETS      = "markets"
+
+	DEFAULT_TIMEOUT    = 120 * time.Second
+	DEFAULT_OUTPUT_DIR = "output"
+)
+
+// CLIOptions - Flags shared by every subcommand
+type CLIOptions struct {
+	Command      string
+	Idea         string
+	Model        string
+	Provider     string
+	Count        int
+	Timeout      time.Duration
+	Output       string
+	Keywords     string
+	CassettePath string
+	CassetteMode string
+	ConfigPath   string
+	Locale       string
+
+	// Budget caps (0 = keep the config value)
+	MaxTokens   int
+	MaxCostUSD  float64
+	MaxDuration time.Duration
+
+	// Structured output (run)
+	Format string
+	Result string
+
+	// Batch only
+	Input   string
+	Workers int
+	Rate    float64
+
+	// Markets only
+	Markets []string
+}
+
+// runCLI - Entry point behind main(), returns the process exit code
+func runCLI(args []string) int {
+	cmd := CMD_RUN
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	opts, err := parseCLIOptions(cmd, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(statusOut, "❌ %v\n", err)
+		return 2
+	}
+
+	// Config is validated once, before any stage runs
+	cfg, err := loadConfig(opts.ConfigPath)
+	if err != nil {
+		fmt.Fprintf(statusOut, "❌ %v\n", err)
+		return 2
+	}
+	cfg = opts.applyOverrides(cfg)
+	if err := cfg.resolveLocale(); err != nil {
+		fmt.Fprintf(statusOut, "❌ invalid config: %v\n", err)
+		return 2
+	}
+	if err := cfg.resolveModels(); err != nil {
+		fmt.Fprintf(statusOut, "❌ %v\n", err)
+		return 2
+	}
+
+	switch cmd {
+	case CMD_RUN:
+		err = runPipelineCommand(opts, cfg)
+	case CMD_KEYWORDS:
+		err = runKeywordsCommand(opts, cfg)
+	case CMD_SEARCH_TERMS:
+		err = runSearchTermsCommand(opts, cfg)
+	case CMD_BRIEF:
+		err = runBriefCommand(opts, cfg)
+	case CMD_BATCH:
+		err = runBatchCommand(opts, cfg)
+	case CMD_MARKETS:
+		err = runMarketsCommand(opts, cfg)
+	}
+
+	if err != nil {
+		fmt.Fprintf(statusOut, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func parseCLIOptions(cmd string, args []string) (CLIOptions, error) {
+	switch cmd {
+	case CMD_RUN, CMD_KEYWORDS, CMD_SEARCH_TERMS, CMD_BRIEF, CMD_BATCH, CMD_MARKETS:
+	default:
+		return CLIOptions{}, fmt.Errorf("unknown command %q (want %s, %s, %s, %s, %s or %s)", cmd, CMD_RUN, CMD_KEYWORDS, CMD_SEARCH_TERMS, CMD_BRIEF, CMD_BATCH, CMD_MARKETS)
+	}
+	var markets string
+
+	opts := CLIOptions{Command: cmd}
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.StringVar(&opts.Idea, "idea", "", "Landing page idea (prompted interactively when empty)")
+	fs.StringVar(&opts.Model, "model", "", "Override the model for the stage(s) being run")
+	fs.StringVar(&opts.Provider, "provider", "", "Override provider routing (comma-separated, in order)")
+	fs.IntVar(&opts.Count, "count", 0, "Number of keywords (keywords) or search terms (search-terms, run)")
+	fs.DurationVar(&opts.Timeout, "timeout", DEFAULT_TIMEOUT, "Overall timeout (per theme in batch mode); stages also have [timeouts] deadlines")
+	fs.StringVar(&opts.CassettePath, "cassette", "", "Cassette file for recording/replaying LLM calls")
+	fs.StringVar(&opts.CassetteMode, "cassette-mode", "", "Cassette mode: record or replay")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file (.toml or .json), defaults to $"+CONFIG_ENV_PATH)
+	fs.StringVar(&opts.Locale, "locale", "", "Prompt language and pattern dictionaries: "+strings.Join(localeCodes(), ", ")+" (default: config)")
+	fs.IntVar(&opts.MaxTokens, "max-tokens", 0, "Token budget per run (0 = config/unlimited)")
+	fs.Float64Var(&opts.MaxCostUSD, "max-cost", 0, "Dollar budget per run (0 = config/unlimited)")
+	fs.DurationVar(&opts.MaxDuration, "max-duration", 0, "Wall-clock budget per run (0 = config/unlimited)")
+
+	switch cmd {
+	case CMD_RUN:
+		fs.StringVar(&opts.Output, "output", DEFAULT_OUTPUT_DIR, "Directory for generated landing pages")
+		fs.StringVar(&opts.Format, "format", OUTPUT_FORMAT_TEXT, "Result format: text, json or csv")
+		fs.StringVar(&opts.Result, "result", "", "Write json/csv results to this file instead of stdout")
+	case CMD_KEYWORDS:
+		fs.StringVar(&opts.Output, "output", "", "Write keywords to this file (one per line)")
+	case CMD_SEARCH_TERMS:
+		fs.StringVar(&opts.Output, "output", "", "Write search terms to this file (one per line)")
+		fs.StringVar(&opts.Keywords, "keywords", "", "Base keywords (comma-separated, defaults to the idea)")
+	case CMD_BRIEF:
+		fs.StringVar(&opts.Output, "output", DEFAULT_OUTPUT_DIR, "Directory for content briefs (content_brief.md/.json per idea)")
+	case CMD_BATCH:
+		fs.StringVar(&opts.Output, "output", DEFAULT_OUTPUT_DIR, "Directory for per-theme results and the summary")
+		fs.StringVar(&opts.Input, "input", "", "CSV or JSONL file of themes")
+		fs.IntVar(&opts.Workers, "workers", DEFAULT_BATCH_WORKERS, "Themes processed concurrently")
+		fs.Float64Var(&opts.Rate, "rate", DEFAULT_BATCH_RATE, "Max LLM requests per second across all workers (0 = unlimited)")
+	case CMD_MARKETS:
+		fs.StringVar(&opts.Output, "output", DEFAULT_OUTPUT_DIR, "Directory for per-market results and the cross-market report")
+		fs.StringVar(&markets, "markets", DEFAULT_MARKETS, "Markets to run (comma-separated country codes)")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if opts.Count < 0 {
+		return opts, fmt.Errorf("--count must be positive, got %d", opts.Count)
+	}
+	if opts.MaxTokens < 0 || opts.MaxCostUSD < 0 || opts.MaxDuration < 0 {
+		return opts, errors.New("--max-tokens, --max-cost and --max-duration must not be negative")
+	}
+	if opts.Timeout <= 0 {
+		return opts, fmt.Errorf("--timeout must be positive, got %s", opts.Timeout)
+	}
+	if cmd == CMD_RUN {
+		if err := validateOutputFormat(opts.Format); err != nil {
+			return opts, err
+		}
+	}
+	if cmd == CMD_MARKETS {
+		if opts.Locale != "" {
+			return opts, errors.New("markets picks the locale per market; drop --locale")
+		}
+		var err error
+		if opts.Markets, err = parseMarkets(markets); err != nil {
+			return opts, err
+		}
+	}
+	if cmd == CMD_BATCH {
+		if opts.Input == "" {
+			return opts, errors.New("batch requires --input")
+		}
+		if opts.Workers <= 0 {
+			return opts, fmt.Errorf("--workers must be positive, got %d", opts.Workers)
+		}
+		if opts.Rate < 0 {
+			return opts, fmt.Errorf("--rate must not be negative, got %g", opts.Rate)
+		}
+	}
+	return opts, nil
+}
+
+// applyOverrides - --model/--provider hit every stage; --count sizes keywords (keywords) or search terms (others)
+func (o CLIOptions) applyOverrides(cfg Config) Config {
+	override := func(settings StageSettings, withCount bool) StageSettings {
+		if o.Model != "" {
+			settings.Model = o.Model
+		}
+		if o.Provider != "" {
+			settings.Providers = splitList(o.Provider)
+		}
+		if withCount && o.Count > 0 {
+			settings.Count = o.Count
+		}
+		return settings
+	}
+	if o.Locale != "" {
+		cfg.Locale = o.Locale
+	}
+	if o.MaxTokens > 0 {
+		cfg.Budget.MaxTokens = o.MaxTokens
+	}
+	if o.MaxCostUSD > 0 {
+		cfg.Budget.MaxCostUSD = o.MaxCostUSD
+	}
+	if o.MaxDuration > 0 {
+		cfg.Budget.MaxDurationMs = int(o.MaxDuration.Milliseconds())
+	}
+	cfg.Keywords.StageSettings = override(cfg.Keywords.StageSettings, o.Command == CMD_KEYWORDS)
+	cfg.SearchTerms.StageSettings = override(cfg.SearchTerms.StageSettings, o.Command != CMD_KEYWORDS)
+	return cfg
+}
+
+// resolveIdea - --idea flag, or the original interactive prompt
+func (o CLIOptions) resolveIdea() (string, error) {
+	idea := strings.TrimSpace(o.Idea)
+	if idea == "" {
+		fmt.Fprint(statusOut, "💡 What landing page idea? (e.g., romance books, thriller audiobooks): ")
+		reader := bufio.NewReader(os.Stdin)
+		line, _ := reader.ReadString('\n')
+		idea = strings.TrimSpace(line)
+	}
+	if idea == "" {
+		return "", errors.New("no idea provided")
+	}
+	return idea, nil
+}
+
+// resolveBackend - Env-selected backend, wrapped or replaced by cassette mode
+func (o CLIOptions) resolveBackend() (LLMBackend, error) {
+	backend, err := applyCassetteMode(newBackendFromEnv(), o.CassetteMode, o.CassettePath)
+	if err != nil {
+		return nil, err
+	}
+	if backend == nil {
+		fmt.Fprintln(statusOut, "⚠️  OPENROUTER_API_KEY not set, using fallback keywords")
+	} else {
+		fmt.Fprintf(statusOut, "🔌 Using %s backend\n", backend.Name())
+	}
+	return backend, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🎬 SUBCOMMANDS
+// ═══════════════════════════════════════════════════════════════════════════
+
+func runPipelineCommand(opts CLIOptions, cfg Config) error {
+	if opts.Format != OUTPUT_FORMAT_TEXT && opts.Result == "" {
+		statusOut = os.Stderr // stdout is reserved for the document
+	}
+	fmt.Fprintln(statusOut, "🤖 Landing Page Agent Started")
+
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	idea, err := opts.resolveIdea()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	fmt.Fprintf(statusOut, "\n💡 Building landing page for: %s\n", idea)
+	fmt.Fprintln(statusOut, "🔄 Generating SEO keywords and must-target search terms...")
+
+	result, err := runPipeline(ctx, backend, idea, cfg)
+	if err != nil {
+		return err
+	}
+	printKeywords(result.Keywords)
+	printSearchTerms(result.TermRecords)
+
+	fmt.Fprintln(statusOut, "\n🏗️  Building landing page...")
+	pageStart := time.Now()
+	page := buildLandingPage(result)
+	path, err := renderLandingPage(page, filepath.Join(opts.Output, slugify(idea)))
+	if err != nil {
+		return err
+	}
+	result.LandingPagePath = path
+	if result.BriefPath, err = writeContentBrief(newContentBrief(result, page), filepath.Dir(path)); err != nil {
+		return err
+	}
+	result.Timings.LandingPageMs = time.Since(pageStart).Milliseconds()
+	result.Timings.TotalMs += result.Timings.LandingPageMs
+	fmt.Fprintf(statusOut, "\n📄 Landing page written to %s\n", path)
+	fmt.Fprintf(statusOut, "📝 Content brief written to %s\n", result.BriefPath)
+
+	printPipelineSummary(result)
+	printUsageReport(result.Usage)
+	if opts.Format == OUTPUT_FORMAT_TEXT {
+		return nil
+	}
+	if err := writeStructuredOutput(opts.Format, opts.Result, []PipelineResult{result}); err != nil {
+		return err
+	}
+	if opts.Result != "" {
+		fmt.Fprintf(statusOut, "📄 %s results written to %s\n", strings.ToUpper(opts.Format), opts.Result)
+	}
+	return nil
+}
+
+func runKeywordsCommand(opts CLIOptions, cfg Config) error {
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	idea, err := opts.resolveIdea()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	usage := NewUsageTracker(cfg.registry)
+	keywords, err := runKeywordStage(ctx, backend, idea, cfg, usage, NewBudget(cfg.Budget, usage))
+	if err != nil {
+		return err
+	}
+	printKeywords(keywords.Keywords)
+	printUsageReport(usage.Report())
+	return writeListOutput(opts.Output, keywords.Keywords)
+}
+
+func runSearchTermsCommand(opts CLIOptions, cfg Config) error {
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	idea, err := opts.resolveIdea()
+	if err != nil {
+		return err
+	}
+
+	keywords := splitList(opts.Keywords)
+	if len(keywords) == 0 {
+		keywords = []string{strings.ToLower(idea)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	usage := NewUsageTracker(cfg.registry)
+	result, err := runSearchTermStage(ctx, backend, idea, keywords, cfg, usage, NewBudget(cfg.Budget, usage))
+	if err != nil {
+		return err
+	}
+	printSearchTerms(result.Records)
+	printUsageReport(usage.Report())
+	return writeListOutput(opts.Output, result.Terms)
+}
+
+// runBriefCommand - The run pipeline without the HTML: just the content brief
+func runBriefCommand(opts CLIOptions, cfg Config) error {
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	idea, err := opts.resolveIdea()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	result, err := runPipeline(ctx, backend, idea, cfg)
+	if err != nil {
+		return err
+	}
+	brief := newContentBrief(result, buildLandingPage(result))
+	path, err := writeContentBrief(brief, filepath.Join(opts.Output, slugify(idea)))
+	if err != nil {
+		return err
+	}
+
+	printContentBrief(brief)
+	printUsageReport(result.Usage)
+	fmt.Fprintf(statusOut, "\n📝 Content brief written to %s (JSON: %s)\n", path, filepath.Join(filepath.Dir(path), BRIEF_JSON_FILENAME))
+	return nil
+}
+
+func runBatchCommand(opts CLIOptions, cfg Config) error {
+	themes, err := readBatchThemes(opts.Input)
+	if err != nil {
+		return err
+	}
+
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	backend = NewRateLimitedBackend(backend, opts.Rate)
+
+	fmt.Fprintf(statusOut, "📦 Batch: %d themes, %d workers, %g req/s\n", len(themes), opts.Workers, opts.Rate)
+	start := time.Now()
+	results := runBatch(context.Background(), backend, themes, opts.Workers, opts.Timeout, cfg)
+
+	summary, err := writeBatchResults(opts.Output, opts.Input, results, time.Since(start))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(statusOut, "\n📦 Batch Summary:")
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	for _, r := range summary.Results {
+		if r.Error != "" {
+			fmt.Fprintf(statusOut, "  ❌ %-30s %s\n", r.Theme, r.Error)
+		} else {
+			fmt.Fprintf(statusOut, "  ✅ %-30s %d keywords, %d terms\n", r.Theme, len(r.Keywords), len(r.SearchTerms))
+		}
+	}
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	printUsageReport(summary.Usage)
+	fmt.Fprintf(statusOut, "\n📦 %d/%d succeeded in %s, report: %s (CSV: %s)\n",
+		summary.Succeeded, summary.Total, time.Since(start).Round(time.Millisecond),
+		filepath.Join(opts.Output, BATCH_SUMMARY_FILE), filepath.Join(opts.Output, BATCH_SUMMARY_CSV))
+	return nil
+}
+
+func runMarketsCommand(opts CLIOptions, cfg Config) error {
+	backend, err := opts.resolveBackend()
+	if err != nil {
+		return err
+	}
+	idea, err := opts.resolveIdea()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(statusOut, "🗺️  %s across %d markets: %s\n", idea, len(opts.Markets), strings.Join(opts.Markets, ", "))
+	summary := runMarkets(context.Background(), backend, idea, opts.Markets, opts.Timeout, cfg)
+	path, err := writeMarketResults(opts.Output, &summary)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range summary.Results {
+		if r.Error != "" {
+			failed++
+			fmt.Fprintf(statusOut, "  ❌ %-4s %s\n", r.Market, r.Error)
+			continue
+		}
+		fmt.Fprintf(statusOut, "\n🌍 %s (%s): score %.1f, %s\n", r.Market, r.Locale, r.Score, r.Status)
+		printSearchTerms(r.TermRecords)
+	}
+	printCrossMarketReport(summary.Report)
+	printUsageReport(summary.Usage)
+	fmt.Fprintf(statusOut, "\n🗺️  %d/%d markets succeeded, report: %s\n", len(summary.Results)-failed, len(summary.Results), path)
+	if failed == len(summary.Results) {
+		return errors.New("every market failed")
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🛠️ HELPERS
+// ═══════════════════════════════════════════════════════════════════════════
+
+// writeListOutput - One item per line; no-op when path is empty
+func writeListOutput(path string, items []string) error {
+	if path == "" {
+		return nil
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(items, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(statusOut, "\n📄 Written to %s\n", path)
+	return nil
+}
+
+// splitList - "a, b,,c" → [a b c]
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
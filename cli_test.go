@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCLIOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cmd  string
+		args []string
+		err  string
+	}{
+		{"run defaults", CMD_RUN, nil, ""},
+		{"unknown command", "publish", nil, `unknown command "publish"`},
+		{"stray argument", CMD_RUN, []string{"--idea", "romance", "extra"}, "unexpected arguments: extra"},
+		{"negative count", CMD_KEYWORDS, []string{"--count", "-1"}, "--count must be positive"},
+		{"zero timeout", CMD_RUN, []string{"--timeout", "0s"}, "--timeout must be positive"},
+		{"negative budget", CMD_RUN, []string{"--max-cost", "-1"}, "must not be negative"},
+		{"bad format", CMD_RUN, []string{"--format", "xml"}, `unknown output format "xml"`},
+		{"format is run only", CMD_KEYWORDS, []string{"--format", "json"}, "flag provided but not defined"},
+		{"batch without input", CMD_BATCH, nil, "batch requires --input"},
+		{"batch zero workers", CMD_BATCH, []string{"--input", "themes.csv", "--workers", "0"}, "--workers must be positive"},
+		{"markets with locale", CMD_MARKETS, []string{"--locale", "sv"}, "drop --locale"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseCLIOptions(tc.cmd, tc.args)
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("err = %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestCLIOverridesReachTheRightStage(t *testing.T) {
+	args := []string{"--model", "custom/model", "--provider", "a, b", "--count", "5", "--locale", "de", "--max-tokens", "900"}
+	for _, tc := range []struct {
+		cmd                       string
+		keywordCount, searchCount int
+	}{
+		{CMD_KEYWORDS, 5, TARGET_SEARCH_TERM_COUNT},
+		{CMD_SEARCH_TERMS, 8, 5},
+		{CMD_RUN, 8, 5},
+	} {
+		opts, err := parseCLIOptions(tc.cmd, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := opts.applyOverrides(defaultConfig())
+		if cfg.Keywords.Count != tc.keywordCount || cfg.SearchTerms.Count != tc.searchCount {
+			t.Errorf("%s: counts %d/%d, want %d/%d", tc.cmd, cfg.Keywords.Count, cfg.SearchTerms.Count, tc.keywordCount, tc.searchCount)
+		}
+		for _, stage := range []StageSettings{cfg.Keywords.StageSettings, cfg.SearchTerms.StageSettings} {
+			if stage.Model != "custom/model" || strings.Join(stage.Providers, "|") != "a|b" {
+				t.Errorf("%s: stage %+v, want --model and --provider on every stage", tc.cmd, stage)
+			}
+		}
+		if cfg.Locale != "de" || cfg.Budget.MaxTokens != 900 {
+			t.Errorf("%s: locale %q, max tokens %d", tc.cmd, cfg.Locale, cfg.Budget.MaxTokens)
+		}
+	}
+}
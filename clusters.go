@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧩 CLUSTERS - Topical Groups of Search Terms → Page Sections
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Fifteen flat terms don't tell a writer how to build the page. Here they
+// are grouped locally (NO API calls unless llm_labels is on):
+//   - "tokens": word overlap (Jaccard) without the theme words every term shares
+//   - "embeddings": cosine similarity from [search_terms.embeddings]
+// Average-linkage merging until no two groups are similar enough.
+//
+// Each cluster gets a primary term (the most central non-question term) and
+// lands in one page section: the biggest cluster is the hero, the next ones
+// become H2 blocks (the last one collecting the overflow), question
+// clusters go to the FAQ.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	CLUSTER_METHOD_TOKENS     = "tokens"
+	CLUSTER_METHOD_EMBEDDINGS = "embeddings"
+
+	CLUSTER_TOKEN_THRESHOLD     = 0.25 // Jaccard: ≈ one shared content word between short terms
+	CLUSTER_EMBEDDING_THRESHOLD = 0.45 // Cosine of hashed n-grams
+
+	SECTION_HERO = "hero"
+	SECTION_H2   = "h2"
+	SECTION_FAQ  = "faq"
+)
+
+// Cluster labelling prompts (only with [clusters] llm_labels = true)
+const (
+	CLUSTER_LABEL_SYSTEM_PROMPT = "You are a SEO content strategist. You name groups of search terms so writers know what each page section is about."
+
+	CLUSTER_LABEL_USER_PROMPT_TEMPLATE = `These search terms for a landing page about "%s" are grouped by topic:
+
+%s
+
+Give every group a short label (2-4 words) in %s that says what its section is about.
+Use the submit_cluster_labels tool with EXACTLY %d labels, in the same order as the groups.`
+)
+
+// ClusterConfig - [clusters]
+type ClusterConfig struct {
+	Method    string  `json:"method"`     // CLUSTER_METHOD_*
+	Threshold float64 `json:"threshold"`  // Min average similarity to merge two groups; 0 = the method's default
+	LLMLabels bool    `json:"llm_labels"` // One extra call (keywords model) to name the clusters
+
+	stopWords map[string]bool // The locale's StopWords, set by resolveLocale
+}
+
+func defaultClusterConfig() ClusterConfig {
+	return ClusterConfig{Method: CLUSTER_METHOD_TOKENS}
+}
+
+// stopWordSet - The locale's stop-words, or the English ones when the config was never resolved
+func (c ClusterConfig) stopWordSet() map[string]bool {
+	if c.stopWords == nil {
+		return defaultStopWordSet
+	}
+	return c.stopWords
+}
+
+// threshold - The configured threshold, or the method's default
+func (c ClusterConfig) threshold() float64 {
+	switch {
+	case c.Threshold > 0:
+		return c.Threshold
+	case c.Method == CLUSTER_METHOD_EMBEDDINGS:
+		return CLUSTER_EMBEDDING_THRESHOLD
+	}
+	return CLUSTER_TOKEN_THRESHOLD
+}
+
+// TermCluster - One topical group of search terms
+type TermCluster struct {
+	ID      int      `json:"id"`
+	Label   string   `json:"label"`
+	Primary string   `json:"primary"` // The term the section is built around
+	Terms   []string `json:"terms"`   // Primary first
+	Intent  string   `json:"intent"`  // Most common intent among the terms
+}
+
+// PageSection - Where clusters land on the page
+type PageSection struct {
+	Kind     string   `json:"kind"` // SECTION_*
+	Heading  string   `json:"heading"`
+	Topic    string   `json:"topic,omitempty"`
+	Clusters []int    `json:"clusters"` // TermCluster.ID
+	Terms    []string `json:"terms"`    // Terms the section has to cover, primary first
+}
+
+// ClusterStageResult - Clusters, where they go on the page, and the labelling call (if any)
+type ClusterStageResult struct {
+	Clusters []TermCluster
+	Sections []PageSection
+	APICalls int
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🎯 STAGE
+// ═══════════════════════════════════════════════════════════════════════════
+
+// runClusterStage - Local clustering and section mapping; a failed labelling call keeps the local labels
+func runClusterStage(ctx context.Context, backend LLMBackend, theme string, records []SearchTermRecord, cfg Config, usage *UsageTracker, budget *Budget) (ClusterStageResult, error) {
+	var embedder Embedder
+	if cfg.Clusters.Method == CLUSTER_METHOD_EMBEDDINGS {
+		var err error
+		if embedder, err = cfg.SearchTerms.Embeddings.New(); err != nil {
+			return ClusterStageResult{}, fmt.Errorf("cluster stage: %w", err)
+		}
+	}
+	clusters, err := clusterTerms(ctx, theme, records, cfg.Clusters, embedder)
+	if err != nil {
+		return ClusterStageResult{}, fmt.Errorf("cluster stage: %w", err)
+	}
+	result := ClusterStageResult{Clusters: clusters}
+
+	if cfg.Clusters.LLMLabels && backend != nil && len(clusters) > 0 {
+		if err := budget.CheckNextCall(); err != nil {
+			log.Printf("💸 Skipping cluster labels, %v", err)
+		} else {
+			backend = NewMeteredBackend(backend, usage, USAGE_STAGE_CLUSTERS)
+			backend = NewCallTimeoutBackend(backend, cfg.Timeouts.CallMs)
+			backend = NewRetryingBackend(backend, cfg.Retry, cfg.Keywords.Fallbacks)
+			result.APICalls++
+			if err := labelClusters(ctx, backend, theme, clusters, cfg); err != nil {
+				log.Printf("⚠️  Cluster labelling failed, keeping local labels: %v", err)
+			}
+		}
+	}
+
+	result.Sections = mapClustersToSections(theme, clusters, records, localeFor(cfg.Locale).Page)
+	log.Printf("🧩 %d search terms → %d clusters → %d page sections", len(records), len(clusters), len(result.Sections))
+	return result, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧮 CLUSTERING - Local Logic
+// ═══════════════════════════════════════════════════════════════════════════
+
+// clusterTerms - Average-linkage merging over pairwise similarity, biggest clusters first
+func clusterTerms(ctx context.Context, theme string, records []SearchTermRecord, cfg ClusterConfig, embedder Embedder) ([]TermCluster, error) {
+	terms := make([]string, len(records))
+	for i, r := range records {
+		terms[i] = r.Term
+	}
+
+	var similarity [][]float64
+	if embedder != nil {
+		var err error
+		if similarity, err = embeddingSimilarity(ctx, embedder, terms, theme, cfg.stopWordSet()); err != nil {
+			return nil, err
+		}
+	} else {
+		similarity = tokenSimilarity(terms, theme, cfg.stopWordSet())
+	}
+
+	var clusters []TermCluster
+	for id, members := range agglomerate(similarity, cfg.threshold()) {
+		cluster := TermCluster{ID: id + 1}
+		primary := primaryMember(members, similarity, records)
+		cluster.Primary = terms[primary]
+		cluster.Terms = []string{terms[primary]}
+		intents := make(map[string]int)
+		for _, m := range members {
+			if m != primary {
+				cluster.Terms = append(cluster.Terms, terms[m])
+			}
+			intents[records[m].Intent]++
+		}
+		cluster.Intent = mostCommon(intents, SEARCH_TERM_INTENTS)
+		cluster.Label = localClusterLabel(cluster.Terms, theme, cfg.stopWordSet())
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// contentWords - Singular words of a term without stop-words and the theme's own words, in order
+func contentWords(term string, themeWords, stopWords map[string]bool) []string {
+	var words []string
+	for _, word := range normalizedWords(term, stopWords) {
+		if word = singularize(word); !themeWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func themeWordSet(theme string, stopWords map[string]bool) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range normalizedWords(theme, stopWords) {
+		set[singularize(word)] = true
+	}
+	return set
+}
+
+// tokenSimilarity - Jaccard overlap of content words (terms with none are similar to nothing)
+func tokenSimilarity(terms []string, theme string, stopWords map[string]bool) [][]float64 {
+	themeWords := themeWordSet(theme, stopWords)
+	sets := make([]map[string]bool, len(terms))
+	for i, term := range terms {
+		sets[i] = make(map[string]bool)
+		for _, word := range contentWords(term, themeWords, stopWords) {
+			sets[i][word] = true
+		}
+	}
+
+	similarity := newMatrix(len(terms))
+	for i := range terms {
+		for j := i + 1; j < len(terms); j++ {
+			shared := 0
+			for word := range sets[i] {
+				if sets[j][word] {
+					shared++
+				}
+			}
+			if union := len(sets[i]) + len(sets[j]) - shared; union > 0 {
+				similarity[i][j] = float64(shared) / float64(union)
+				similarity[j][i] = similarity[i][j]
+			}
+		}
+	}
+	return similarity
+}
+
+// embeddingSimilarity - Cosine of the terms' vectors, theme words removed first
+func embeddingSimilarity(ctx context.Context, embedder Embedder, terms []string, theme string, stopWords map[string]bool) ([][]float64, error) {
+	themeWords := themeWordSet(theme, stopWords)
+	texts := make([]string, len(terms))
+	for i, term := range terms {
+		var kept []string
+		for _, word := range strings.Fields(strings.ToLower(term)) {
+			if !themeWords[singularize(word)] {
+				kept = append(kept, word)
+			}
+		}
+		texts[i] = strings.Join(kept, " ")
+	}
+
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", embedder.Name(), err)
+	}
+	if len(vectors) != len(terms) {
+		return nil, fmt.Errorf("%s: %d vectors for %d terms", embedder.Name(), len(vectors), len(terms))
+	}
+	similarity := newMatrix(len(terms))
+	for i := range terms {
+		for j := i + 1; j < len(terms); j++ {
+			similarity[i][j] = cosine(vectors[i], vectors[j])
+			similarity[j][i] = similarity[i][j]
+		}
+	}
+	return similarity, nil
+}
+
+func newMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	return m
+}
+
+// agglomerate - Merge the two most similar groups (average linkage) while that similarity
+// reaches threshold. Groups come back biggest first, then by first member; members in input order.
+func agglomerate(similarity [][]float64, threshold float64) [][]int {
+	groups := make([][]int, len(similarity))
+	for i := range groups {
+		groups[i] = []int{i}
+	}
+
+	linkage := func(a, b []int) float64 {
+		var total float64
+		for _, i := range a {
+			for _, j := range b {
+				total += similarity[i][j]
+			}
+		}
+		return total / float64(len(a)*len(b))
+	}
+
+	for len(groups) > 1 {
+		bestA, bestB, best := -1, -1, threshold
+		for a := range groups {
+			for b := a + 1; b < len(groups); b++ {
+				if s := linkage(groups[a], groups[b]); s >= best && (bestA < 0 || s > best) {
+					bestA, bestB, best = a, b, s
+				}
+			}
+		}
+		if bestA < 0 {
+			break
+		}
+		merged := append(append([]int(nil), groups[bestA]...), groups[bestB]...)
+		sort.Ints(merged)
+		groups[bestA] = merged
+		groups = append(groups[:bestB], groups[bestB+1:]...)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if len(groups[a]) != len(groups[b]) {
+			return len(groups[a]) > len(groups[b])
+		}
+		return groups[a][0] < groups[b][0]
+	})
+	return groups
+}
+
+// primaryMember - Most central member (mean similarity to the others); questions only when
+// the cluster has nothing else, since they belong in the FAQ rather than a heading
+func primaryMember(members []int, similarity [][]float64, records []SearchTermRecord) int {
+	best, bestScore := members[0], -1.0
+	for _, m := range members {
+		score := 0.0
+		for _, other := range members {
+			if other != m {
+				score += similarity[m][other]
+			}
+		}
+		if len(members) > 1 {
+			score /= float64(len(members) - 1)
+		}
+		if !containsString(records[m].Patterns, "questions") {
+			score += 1 // Any statement beats any question
+		}
+		if score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	return best
+}
+
+// localClusterLabel - The content word most terms share ("Audiobook"), else the first term
+func localClusterLabel(terms []string, theme string, stopWords map[string]bool) string {
+	if len(terms) > 1 {
+		themeWords := themeWordSet(theme, stopWords)
+		counts := make(map[string]int)
+		var order []string
+		for _, term := range terms {
+			seen := make(map[string]bool)
+			for _, word := range contentWords(term, themeWords, stopWords) {
+				if seen[word] {
+					continue
+				}
+				if counts[word] == 0 {
+					order = append(order, word)
+				}
+				seen[word] = true
+				counts[word]++
+			}
+		}
+		if label := mostCommon(counts, order); counts[label] > 1 {
+			return capitalizeFirst(label)
+		}
+	}
+	return capitalizeFirst(terms[0])
+}
+
+// mostCommon - The key with the highest count; ties go to the earliest in order
+func mostCommon(counts map[string]int, order []string) string {
+	best := ""
+	for _, key := range order {
+		if counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return best
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏗️ PAGE MAPPING
+// ═══════════════════════════════════════════════════════════════════════════
+
+// mapClustersToSections - Biggest statement cluster → hero, the next ones → H2 blocks (at most
+// LANDING_PAGE_H2_COUNT, the last one collecting whatever doesn't fit), question clusters → FAQ
+func mapClustersToSections(theme string, clusters []TermCluster, records []SearchTermRecord, wording PageCopy) []PageSection {
+	isQuestion := make(map[string]bool)
+	for _, r := range records {
+		isQuestion[r.Term] = containsString(r.Patterns, "questions")
+	}
+
+	var statements []TermCluster
+	faq := PageSection{Kind: SECTION_FAQ, Heading: wording.FAQHeading, Clusters: []int{}}
+	for _, cluster := range clusters {
+		questions := 0
+		for _, term := range cluster.Terms {
+			if isQuestion[term] {
+				questions++
+			}
+		}
+		if questions*2 > len(cluster.Terms) {
+			faq.Clusters = append(faq.Clusters, cluster.ID)
+			faq.Terms = append(faq.Terms, cluster.Terms...)
+		} else {
+			statements = append(statements, cluster)
+		}
+	}
+
+	// Every term was a question: the theme itself carries the hero
+	sections := []PageSection{{Kind: SECTION_HERO, Heading: titleCase(theme), Clusters: []int{}, Terms: []string{}}}
+	for i, cluster := range statements {
+		section := PageSection{
+			Kind:     SECTION_H2,
+			Heading:  capitalizeFirst(cluster.Primary),
+			Topic:    cluster.Label,
+			Clusters: []int{cluster.ID},
+			Terms:    cluster.Terms,
+		}
+		switch {
+		case i == 0:
+			section.Kind = SECTION_HERO
+			sections[0] = section
+		case i < LANDING_PAGE_H2_COUNT || len(statements) == LANDING_PAGE_H2_COUNT+1:
+			sections = append(sections, section)
+		case i == LANDING_PAGE_H2_COUNT:
+			section.Heading = fmt.Sprintf(wording.MoreHeading, theme)
+			section.Topic = ""
+			sections = append(sections, section)
+		default:
+			last := &sections[len(sections)-1]
+			last.Clusters = append(last.Clusters, cluster.ID)
+			last.Terms = append(last.Terms, cluster.Terms...)
+		}
+	}
+
+	if len(faq.Terms) > 0 {
+		sections = append(sections, faq)
+	}
+	return sections
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏷️ LLM LABELS - Optional, One Call for All Clusters
+// ═══════════════════════════════════════════════════════════════════════════
+
+func labelClusters(ctx context.Context, backend LLMBackend, theme string, clusters []TermCluster, cfg Config) error {
+	locale, err := lookupLocale(cfg.Locale)
+	if err != nil {
+		return err
+	}
+	var groups []string
+	for i, cluster := range clusters {
+		groups = append(groups, fmt.Sprintf("%d. %s", i+1, strings.Join(cluster.Terms, "; ")))
+	}
+
+	resp, err := backend.ChatWithTools(withUsageStage(ctx, USAGE_STAGE_CLUSTERS), ChatRequest{
+		Model:        cfg.Keywords.Model,
+		Providers:    cfg.Keywords.Providers,
+		SystemPrompt: CLUSTER_LABEL_SYSTEM_PROMPT,
+		UserPrompt:   fmt.Sprintf(CLUSTER_LABEL_USER_PROMPT_TEMPLATE, theme, strings.Join(groups, "\n"), locale.Language, len(clusters)),
+		Tools: []ToolDefinition{
+			{
+				Name:        "submit_cluster_labels",
+				Description: "Submit one short label per search term group",
+				Parameters: json.RawMessage(fmt.Sprintf(`{
+					"type": "object",
+					"properties": {
+						"labels": {
+							"type": "array",
+							"items": {"type": "string"},
+							"minItems": %d,
+							"maxItems": %d
+						}
+					},
+					"required": ["labels"]
+				}`, len(clusters), len(clusters))),
+			},
+		},
+		Temperature: cfg.Keywords.Spec.Temperature(cfg.Keywords.Temperature),
+	})
+	if err != nil {
+		return err
+	}
+
+	list, err := repairToolList(resp, "labels")
+	if err != nil {
+		return err
+	}
+	if len(list.Items) != len(clusters) {
+		return fmt.Errorf("expected %d labels, got %d", len(clusters), len(list.Items))
+	}
+	for i := range clusters {
+		clusters[i].Label = list.Items[i]
+	}
+	return nil
+}
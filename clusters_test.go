@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClustersGroupSharedTopicsAndPickStatementPrimaries(t *testing.T) {
+	records := buildTermRecords(cassetteRefinedTerms, nil, []string{"romance books"}, defaultPatternSet, defaultStopWordSet)
+
+	clusters, err := clusterTerms(context.Background(), "romance books", records, defaultClusterConfig(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := clusters[0]
+	want := "best romance audiobooks|unlimited romance audiobooks|romance audiobooks for commute|romance audiobooks vs ebooks"
+	if strings.Join(audio.Terms, "|") != want || audio.Primary != "best romance audiobooks" || audio.Label != "Audiobook" {
+		t.Errorf("biggest cluster = %+v, want the audiobook terms with a statement as primary", audio)
+	}
+
+	seen := 0
+	for _, c := range clusters {
+		seen += len(c.Terms)
+		if c.Primary != c.Terms[0] {
+			t.Errorf("cluster %d: primary %q is not listed first", c.ID, c.Primary)
+		}
+	}
+	if seen != len(cassetteRefinedTerms) {
+		t.Errorf("%d terms clustered, want every one of %d", seen, len(cassetteRefinedTerms))
+	}
+
+	// Embeddings group by spelling too, so the theme-free "enemies to lovers audiobooks" joins
+	embedder, _ := defaultEmbeddingConfig().New()
+	semantic, err := clusterTerms(context.Background(), "romance books", records, ClusterConfig{Method: CLUSTER_METHOD_EMBEDDINGS}, embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsString(semantic[0].Terms, "enemies to lovers audiobooks") || !containsString(semantic[0].Terms, "best romance audiobooks") {
+		t.Errorf("embedding clusters = %+v", semantic[0])
+	}
+}
+
+func TestClusterStageMapsSectionsAndWritesBrief(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.Clusters.LLMLabels = true
+	records := buildTermRecords(cassetteRefinedTerms, nil, []string{"romance books"}, defaultPatternSet, defaultStopWordSet)
+
+	labels := make([]string, 12)
+	for i := range labels {
+		labels[i] = "Label " + string(rune('A'+i))
+	}
+	backend := &scriptedBackend{steps: []scriptedStep{{terms: labels, field: "labels"}}}
+	stage, err := runClusterStage(context.Background(), backend, "romance books", records, cfg, NewUsageTracker(cfg.registry), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stage.APICalls != 1 || len(stage.Clusters) != 12 || stage.Clusters[0].Label != "Label A" {
+		t.Fatalf("stage = %+v, want 12 LLM-labelled clusters from one call", stage)
+	}
+
+	var kinds []string
+	for _, s := range stage.Sections {
+		kinds = append(kinds, s.Kind)
+	}
+	if strings.Join(kinds, ",") != "hero,h2,h2,h2,h2,h2,h2,faq" {
+		t.Fatalf("sections = %v, want hero, %d H2 blocks and the FAQ", kinds, LANDING_PAGE_H2_COUNT)
+	}
+	if more := stage.Sections[6]; more.Heading != "More romance books" || len(more.Clusters) != 3 {
+		t.Errorf("overflow section = %+v, want the three leftover clusters", more)
+	}
+	if faq := stage.Sections[7]; strings.Join(faq.Terms, "|") != "where to listen to romance books|how to find spicy romance ebooks|which romance app works offline" {
+		t.Errorf("FAQ terms = %q, want the questions", faq.Terms)
+	}
+
+	dir := t.TempDir()
+	result := PipelineResult{Theme: "romance books", Locale: "en", TermRecords: records, Clusters: stage.Clusters, Sections: stage.Sections}
+	path, err := writeContentBrief(newContentBrief(result, buildLandingPage(result)), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown, _ := os.ReadFile(path)
+	for _, want := range []string{"# Content brief: Romance Books", "### Hero: Best Romance Audiobooks: Listen and Read Without Limits", "- best romance audiobooks (commercial · audiobook) **primary**", "### FAQ: Frequently asked questions"} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("brief lacks %q:\n%s", want, markdown)
+		}
+	}
+	var brief ContentBrief
+	data, _ := os.ReadFile(filepath.Join(dir, BRIEF_JSON_FILENAME))
+	if err := json.Unmarshal(data, &brief); err != nil || len(brief.Sections) != 8 || len(brief.Clusters) != 12 {
+		t.Errorf("JSON brief: %v, %d sections, %d clusters", err, len(brief.Sections), len(brief.Clusters))
+	}
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⚙️ CONFIG - Models, Prompts and Thresholds Without Recompiling
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Precedence (lowest → highest):
+//   1. Built-in defaults (the constants in keyword.go / search_terms*.go)
+//   2. Config file (--config or NX_LANDER_CONFIG; .toml or .json)
+//   3. Env vars: NX_LANDER_<SECTION>_<KEY>, e.g. NX_LANDER_SEARCH_TERMS_MIN_DIVERSITY=0.7
+//   4. CLI flags (--model, --provider, --count, --locale, --max-tokens, --max-cost, --max-duration)
+//
+// Everything is validated once at startup; bad values fail fast with the key name.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	CONFIG_ENV_PATH   = "NX_LANDER_CONFIG"
+	CONFIG_ENV_PREFIX = "NX_LANDER_"
+)
+
+// Config - Root of the config file
+type Config struct {
+	Locale      string                `json:"locale"` // Prompt language and pattern dictionaries (locales.go)
+	Keywords    KeywordStageConfig    `json:"keywords"`
+	SearchTerms SearchTermStageConfig `json:"search_terms"`
+	Models      []ModelSpec           `json:"models"` // [[models]]: added to (or replacing) the built-in registry
+	Retry       RetryPolicy           `json:"retry"`
+	Budget      BudgetLimits          `json:"budget"` // Per run (per theme in batch mode)
+	Timeouts    StageTimeouts         `json:"timeouts"`
+	Clusters    ClusterConfig         `json:"clusters"`
+
+	registry *ModelRegistry // Built by resolveModels; prices usage
+}
+
+// StageSettings - Model routing and output size for one pipeline stage
+type StageSettings struct {
+	Model     string   `json:"model"`
+	Providers []string `json:"providers"` // Aliases ("Google") or routing slugs ("google-vertex")
+	Count     int      `json:"count"`
+
+	// Tried in order when the primary model keeps failing ([[<stage>.fallbacks]])
+	Fallbacks []StageRouting `json:"fallbacks"`
+
+	Spec ModelSpec `json:"-"` // Filled in by resolveModels
+}
+
+// KeywordStageConfig - [keywords]
+type KeywordStageConfig struct {
+	StageSettings
+	MaxRefinementIterations int      `json:"max_refinement_iterations"`
+	MinAngleCoverage        int      `json:"min_angle_coverage"`  // Out of format, intent, value, use_case
+	MinThemeRelevance       float64  `json:"min_theme_relevance"` // Share of keywords mentioning the theme
+	Temperature             *float32 `json:"temperature"`         // Unset = model default; 0 is honoured
+	SystemPrompt            string   `json:"system_prompt"`
+	UserPromptTemplate      string   `json:"user_prompt_template"`            // %d count, %s theme
+	RefinementUserTemplate  string   `json:"refinement_user_prompt_template"` // %d, %s theme, %s keywords, %s issues, %d count
+
+	angles    *PatternSet     // Built by resolveLocale from the locale's KeywordAngles
+	stopWords map[string]bool // The locale's StopWords, set by resolveLocale
+}
+
+// angleSet - The locale's keyword angles, or the English ones when the config was never resolved
+func (k KeywordStageConfig) angleSet() *PatternSet {
+	if k.angles == nil {
+		return defaultKeywordAngleSet
+	}
+	return k.angles
+}
+
+// stopWordSet - The locale's stop-words, or the English ones when the config was never resolved
+func (k KeywordStageConfig) stopWordSet() map[string]bool {
+	if k.stopWords == nil {
+		return defaultStopWordSet
+	}
+	return k.stopWords
+}
+
+// SearchTermStageConfig - [search_terms]
+type SearchTermStageConfig struct {
+	StageSettings
+	MaxRefinementIterations int               `json:"max_refinement_iterations"`
+	MinPatternCoverage      int               `json:"min_pattern_coverage"` // Out of the enabled pattern rules
+	MinDiversity            float64           `json:"min_diversity"`
+	DuplicateSimilarity     float64           `json:"duplicate_similarity"`   // Normalised edit similarity that makes two terms one (dedupe.go)
+	InitialTemperature      *float32          `json:"initial_temperature"`    // Unset = model default
+	RefinementTemperature   *float32          `json:"refinement_temperature"` // Unset = model default
+	Prompts                 SearchTermPrompts `json:"prompts"`
+	Scoring                 ScoringWeights    `json:"scoring"`
+	Embeddings              EmbeddingConfig   `json:"embeddings"`
+	Patterns                []PatternRule     `json:"patterns"` // [[search_terms.patterns]]: added to (or replacing) the built-in rules
+
+	patterns  *PatternSet     // Built by resolvePatterns
+	stopWords map[string]bool // The locale's StopWords, set by resolveLocale
+}
+
+// patternSet - The resolved rules, or the built-ins when the config was never resolved
+func (s SearchTermStageConfig) patternSet() *PatternSet {
+	if s.patterns == nil {
+		return defaultPatternSet
+	}
+	return s.patterns
+}
+
+// stopWordSet - The locale's stop-words, or the English ones when the config was never resolved
+func (s SearchTermStageConfig) stopWordSet() map[string]bool {
+	if s.stopWords == nil {
+		return defaultStopWordSet
+	}
+	return s.stopWords
+}
+
+// SearchTermPrompts - [search_terms.prompts]
+type SearchTermPrompts struct {
+	InitialSystem          string `json:"initial_system"`
+	InitialUserTemplate    string `json:"initial_user_template"` // %d count, %s theme, %s keywords, %d count
+	RefinementSystem       string `json:"refinement_system"`
+	RefinementUserTemplate string `json:"refinement_user_template"` // %d, %s theme, %s terms, %s missing, %d, %d
+	TopUpUserTemplate      string `json:"top_up_user_template"`     // %d missing, %s theme, %s terms, %d missing
+}
+
+// defaultConfig - The behaviour you get with no config file at all
+func defaultConfig() Config {
+	return Config{
+		Locale: DEFAULT_LOCALE,
+		Keywords: KeywordStageConfig{
+			StageSettings:           StageSettings{Model: KEYWORD_MODEL, Providers: KEYWORD_PROVIDERS, Count: KEYWORD_COUNT},
+			MaxRefinementIterations: KEYWORD_MAX_REFINEMENT_ITERATIONS,
+			MinAngleCoverage:        KEYWORD_MIN_ANGLE_COVERAGE,
+			MinThemeRelevance:       KEYWORD_MIN_THEME_RELEVANCE,
+			Temperature:             float32Ptr(0.7),
+			SystemPrompt:            KEYWORD_SYSTEM_PROMPT,
+			UserPromptTemplate:      KEYWORD_USER_PROMPT_TEMPLATE,
+			RefinementUserTemplate:  KEYWORD_REFINEMENT_USER_PROMPT_TEMPLATE,
+		},
+		SearchTerms: SearchTermStageConfig{
+			StageSettings:           StageSettings{Model: SEARCH_TERMS_MODEL, Providers: SEARCH_TERMS_PROVIDERS, Count: TARGET_SEARCH_TERM_COUNT},
+			MaxRefinementIterations: MAX_REFINEMENT_ITERATIONS,
+			MinPatternCoverage:      MIN_PATTERN_COVERAGE,
+			MinDiversity:            MIN_DIVERSITY_SCORE,
+			DuplicateSimilarity:     DEFAULT_DUPLICATE_SIMILARITY,
+			InitialTemperature:      float32Ptr(0.8), // Creative but focused
+			RefinementTemperature:   float32Ptr(0.7), // Slightly more deterministic for refinement
+			Prompts: SearchTermPrompts{
+				InitialSystem:          INITIAL_GENERATION_SYSTEM_PROMPT,
+				InitialUserTemplate:    INITIAL_GENERATION_USER_PROMPT_TEMPLATE,
+				RefinementSystem:       REFINEMENT_SYSTEM_PROMPT,
+				RefinementUserTemplate: REFINEMENT_USER_PROMPT_TEMPLATE,
+				TopUpUserTemplate:      TOP_UP_USER_PROMPT_TEMPLATE,
+			},
+			Scoring:    defaultScoringWeights(),
+			Embeddings: defaultEmbeddingConfig(),
+		},
+		Retry:    defaultRetryPolicy(),
+		Timeouts: defaultStageTimeouts(),
+		Clusters: defaultClusterConfig(),
+	}
+}
+
+// loadConfig - Defaults + optional file + env overrides, validated
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+
+	if path == "" {
+		path = os.Getenv(CONFIG_ENV_PATH)
+	}
+	if path != "" {
+		if err := mergeConfigFile(&cfg, path); err != nil {
+			return cfg, err
+		}
+		log.Printf("⚙️  Loaded config from %s", path)
+	}
+
+	if err := applyEnvOverrides(reflect.ValueOf(&cfg).Elem(), CONFIG_ENV_PREFIX); err != nil {
+		return cfg, err
+	}
+	if err := cfg.resolveLocale(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// resolveModels - Looks up every stage model in the registry and resolves provider aliases.
+// Runs after CLI overrides, so --model/--provider get the same validation as the file.
+func (c *Config) resolveModels() error {
+	registry, err := NewModelRegistry(c.Models)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	c.registry = registry
+
+	resolve := func(stage string, settings *StageSettings) error {
+		spec, err := registry.Lookup(settings.Model)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stage, err)
+		}
+		if !spec.SupportsTools {
+			return fmt.Errorf("%s: model %s does not support tool calling", stage, spec.Name)
+		}
+		providers, err := spec.ResolveProviders(settings.Providers)
+		if err != nil {
+			return fmt.Errorf("%s: %w", stage, err)
+		}
+		settings.Providers = providers
+		settings.Spec = spec
+
+		for i, fallback := range settings.Fallbacks {
+			spec, err := registry.Lookup(fallback.Model)
+			if err != nil {
+				return fmt.Errorf("%s.fallbacks[%d]: %w", stage, i, err)
+			}
+			if !spec.SupportsTools {
+				return fmt.Errorf("%s.fallbacks[%d]: model %s does not support tool calling", stage, i, spec.Name)
+			}
+			if settings.Fallbacks[i].Providers, err = spec.ResolveProviders(fallback.Providers); err != nil {
+				return fmt.Errorf("%s.fallbacks[%d]: %w", stage, i, err)
+			}
+		}
+		return nil
+	}
+
+	return errors.Join(
+		resolve("keywords", &c.Keywords.StageSettings),
+		resolve("search_terms", &c.SearchTerms.StageSettings),
+	)
+}
+
+// resolveLocale - Swaps built-in prompts for the locale's (customised ones are kept),
+// then merges [[search_terms.patterns]] into the locale's rules and compiles them.
+// Runs again after CLI overrides, so --locale works like locale = "..." in the file.
+func (c *Config) resolveLocale() error {
+	locale, err := lookupLocale(c.Locale)
+	if err != nil {
+		return err
+	}
+	c.Locale = locale.Code
+	prompts := locale.Prompts()
+
+	swap := func(value *string, localized string, field func(LocalePrompts) string) {
+		if isBuiltinPrompt(*value, field) {
+			*value = localized
+		}
+	}
+	swap(&c.Keywords.SystemPrompt, prompts.KeywordSystem, func(p LocalePrompts) string { return p.KeywordSystem })
+	swap(&c.Keywords.UserPromptTemplate, prompts.KeywordUserTemplate, func(p LocalePrompts) string { return p.KeywordUserTemplate })
+	swap(&c.Keywords.RefinementUserTemplate, prompts.KeywordRefinementTemplate, func(p LocalePrompts) string { return p.KeywordRefinementTemplate })
+	s := &c.SearchTerms.Prompts
+	swap(&s.InitialSystem, prompts.SearchTerms.InitialSystem, func(p LocalePrompts) string { return p.SearchTerms.InitialSystem })
+	swap(&s.InitialUserTemplate, prompts.SearchTerms.InitialUserTemplate, func(p LocalePrompts) string { return p.SearchTerms.InitialUserTemplate })
+	swap(&s.RefinementSystem, prompts.SearchTerms.RefinementSystem, func(p LocalePrompts) string { return p.SearchTerms.RefinementSystem })
+	swap(&s.RefinementUserTemplate, prompts.SearchTerms.RefinementUserTemplate, func(p LocalePrompts) string { return p.SearchTerms.RefinementUserTemplate })
+	swap(&s.TopUpUserTemplate, prompts.SearchTerms.TopUpUserTemplate, func(p LocalePrompts) string { return p.SearchTerms.TopUpUserTemplate })
+
+	patterns, err := NewPatternSet(locale.Patterns, c.SearchTerms.Patterns)
+	if err != nil {
+		return err
+	}
+	c.SearchTerms.patterns = patterns
+	c.Keywords.angles = mustPatternSet(keywordAngleRules(locale.KeywordAngles), nil)
+	stopWords := stopWordSet(locale.StopWords)
+	c.Keywords.stopWords, c.SearchTerms.stopWords, c.Clusters.stopWords = stopWords, stopWords, stopWords
+	return nil
+}
+
+// mergeConfigFile - Keys present in the file overwrite defaults; absent keys keep them
+func mergeConfigFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		// TOML → generic tree → JSON, so both formats share one strict decoder
+		var tree map[string]any
+		if _, err := toml.Decode(string(data), &tree); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+		if raw, err = json.Marshal(tree); err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+	case ".json":
+		raw = data
+	default:
+		return fmt.Errorf("unsupported config format %s (want .toml or .json)", path)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields() // Typos should fail loudly, not be ignored
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnvOverrides - Walks the config by json tag: search_terms.min_diversity → NX_LANDER_SEARCH_TERMS_MIN_DIVERSITY
+func applyEnvOverrides(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+
+		// Embedded structs (StageSettings) share their parent's prefix
+		if field.Anonymous {
+			if err := applyEnvOverrides(value, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+
+		if value.Kind() == reflect.Struct {
+			if err := applyEnvOverrides(value, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromString(value, raw); err != nil {
+			return fmt.Errorf("env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setFromString(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		// Optional values (temperatures): setting the env var sets the value
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("expected true/false, got %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ✅ VALIDATION
+// ═══════════════════════════════════════════════════════════════════════════
+
+// Validate - Collects every problem instead of stopping at the first
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	k := c.Keywords
+	check(k.Model != "", "keywords.model must not be empty")
+	check(k.Count > 0, "keywords.count must be positive, got %d", k.Count)
+	check(validTemperature(k.Temperature), "keywords.temperature must be within [0, 2], got %g", derefFloat32(k.Temperature))
+	check(k.SystemPrompt != "", "keywords.system_prompt must not be empty")
+	check(templateAccepts(k.UserPromptTemplate, 1, "theme"),
+		"keywords.user_prompt_template must take exactly (%%d count, %%s theme)")
+	check(templateAccepts(k.RefinementUserTemplate, 1, "theme", "keywords", "issues", 1),
+		"keywords.refinement_user_prompt_template must take exactly (%%d, %%s theme, %%s keywords, %%s issues, %%d count)")
+	check(k.MaxRefinementIterations >= 0, "keywords.max_refinement_iterations must not be negative, got %d", k.MaxRefinementIterations)
+	check(k.MinAngleCoverage >= 0 && k.MinAngleCoverage <= k.angleSet().Len(),
+		"keywords.min_angle_coverage must be within [0, %d], got %d", k.angleSet().Len(), k.MinAngleCoverage)
+	check(k.MinThemeRelevance >= 0 && k.MinThemeRelevance <= 1, "keywords.min_theme_relevance must be within [0, 1], got %g", k.MinThemeRelevance)
+
+	s := c.SearchTerms
+	check(s.Model != "", "search_terms.model must not be empty")
+	check(s.Count > 0, "search_terms.count must be positive, got %d", s.Count)
+	check(s.MaxRefinementIterations >= 0, "search_terms.max_refinement_iterations must not be negative, got %d", s.MaxRefinementIterations)
+	check(s.MinPatternCoverage >= 0 && s.MinPatternCoverage <= s.patternSet().Len(),
+		"search_terms.min_pattern_coverage must be within [0, %d], got %d", s.patternSet().Len(), s.MinPatternCoverage)
+	check(s.MinDiversity >= 0 && s.MinDiversity <= 1, "search_terms.min_diversity must be within [0, 1], got %g", s.MinDiversity)
+	check(s.DuplicateSimilarity > 0 && s.DuplicateSimilarity <= 1, "search_terms.duplicate_similarity must be within (0, 1], got %g", s.DuplicateSimilarity)
+	check(validTemperature(s.InitialTemperature), "search_terms.initial_temperature must be within [0, 2], got %g", derefFloat32(s.InitialTemperature))
+	check(validTemperature(s.RefinementTemperature), "search_terms.refinement_temperature must be within [0, 2], got %g", derefFloat32(s.RefinementTemperature))
+	check(s.Prompts.InitialSystem != "", "search_terms.prompts.initial_system must not be empty")
+	check(s.Prompts.RefinementSystem != "", "search_terms.prompts.refinement_system must not be empty")
+	check(templateAccepts(s.Prompts.InitialUserTemplate, 1, "theme", "keywords", 1),
+		"search_terms.prompts.initial_user_template must take exactly (%%d count, %%s theme, %%s keywords, %%d count)")
+	check(templateAccepts(s.Prompts.RefinementUserTemplate, 1, "theme", "terms", "missing", 1, 1),
+		"search_terms.prompts.refinement_user_template must take exactly (%%d, %%s theme, %%s terms, %%s missing, %%d, %%d)")
+	check(templateAccepts(s.Prompts.TopUpUserTemplate, 1, "theme", "terms", 1),
+		"search_terms.prompts.top_up_user_template must take exactly (%%d missing, %%s theme, %%s terms, %%d missing)")
+
+	w := s.Scoring
+	for name, weight := range map[string]float64{
+		"patterns": w.Patterns, "diversity": w.Diversity, "semantic_diversity": w.SemanticDiversity, "length_mix": w.LengthMix, "theme_relevance": w.ThemeRelevance,
+		"duplicate_penalty": w.DuplicatePenalty, "count_penalty": w.CountPenalty, "min_improvement": w.MinImprovement,
+	} {
+		check(weight >= 0, "search_terms.scoring.%s must not be negative, got %g", name, weight)
+	}
+	check(w.positiveWeight() > 0, "search_terms.scoring needs at least one positive signal weight")
+	check(w.TargetScore >= 0 && w.TargetScore <= 100, "search_terms.scoring.target_score must be within [0, 100], got %g", w.TargetScore)
+
+	e := s.Embeddings
+	_, err := e.New()
+	check(err == nil, "search_terms.embeddings.embedder: %v", err)
+	check(e.Dimensions > 0, "search_terms.embeddings.dimensions must be positive, got %d", e.Dimensions)
+	check(e.NGram >= 1 && e.NGram <= 5, "search_terms.embeddings.ngram must be within [1, 5], got %d", e.NGram)
+	check(e.NearDuplicateThreshold > 0 && e.NearDuplicateThreshold <= 1,
+		"search_terms.embeddings.near_duplicate_threshold must be within (0, 1], got %g", e.NearDuplicateThreshold)
+
+	r := c.Retry
+	check(r.MaxAttempts >= 1, "retry.max_attempts must be at least 1, got %d", r.MaxAttempts)
+	check(r.InitialBackoffMs >= 0, "retry.initial_backoff_ms must not be negative, got %d", r.InitialBackoffMs)
+	check(r.MaxBackoffMs >= r.InitialBackoffMs, "retry.max_backoff_ms must be at least initial_backoff_ms, got %d", r.MaxBackoffMs)
+	check(r.Multiplier >= 1, "retry.multiplier must be at least 1, got %g", r.Multiplier)
+	check(r.Jitter >= 0 && r.Jitter <= 1, "retry.jitter must be within [0, 1], got %g", r.Jitter)
+
+	b := c.Budget
+	check(b.MaxTokens >= 0, "budget.max_tokens must not be negative, got %d", b.MaxTokens)
+	check(b.MaxCostUSD >= 0, "budget.max_cost_usd must not be negative, got %g", b.MaxCostUSD)
+	check(b.MaxDurationMs >= 0, "budget.max_duration_ms must not be negative, got %d", b.MaxDurationMs)
+
+	to := c.Timeouts
+	check(to.KeywordsMs >= 0, "timeouts.keywords_ms must not be negative, got %d", to.KeywordsMs)
+	check(to.SearchTermsMs >= 0, "timeouts.search_terms_ms must not be negative, got %d", to.SearchTermsMs)
+	check(to.CallMs >= 0, "timeouts.call_ms must not be negative, got %d", to.CallMs)
+	if to.CallMs > 0 && r.MaxAttempts >= 1 {
+		chain := retryChainMs(to.CallMs, r)
+		check(to.KeywordsMs == 0 || to.KeywordsMs >= chain,
+			"timeouts.keywords_ms must fit call_ms × retry.max_attempts plus backoff (%dms), got %d", chain, to.KeywordsMs)
+		check(to.SearchTermsMs == 0 || to.SearchTermsMs >= chain,
+			"timeouts.search_terms_ms must fit call_ms × retry.max_attempts plus backoff (%dms), got %d", chain, to.SearchTermsMs)
+	}
+
+	cl := c.Clusters
+	check(cl.Method == CLUSTER_METHOD_TOKENS || cl.Method == CLUSTER_METHOD_EMBEDDINGS,
+		"clusters.method must be %q or %q, got %q", CLUSTER_METHOD_TOKENS, CLUSTER_METHOD_EMBEDDINGS, cl.Method)
+	check(cl.Method != CLUSTER_METHOD_EMBEDDINGS || e.Embedder != "", "clusters.method = %q needs search_terms.embeddings.embedder", CLUSTER_METHOD_EMBEDDINGS)
+	check(cl.Threshold >= 0 && cl.Threshold <= 1, "clusters.threshold must be within [0, 1], got %g", cl.Threshold)
+
+	return errors.Join(errs...)
+}
+
+// validTemperature - Unset is fine (model default), otherwise within [0, 2]
+func validTemperature(t *float32) bool {
+	return t == nil || (*t >= 0 && *t <= 2)
+}
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
+
+func derefFloat32(f *float32) float32 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
+// templateAccepts - True when the template consumes exactly these args with matching verbs
+func templateAccepts(template string, args ...any) bool {
+	if template == "" {
+		return false
+	}
+	return !strings.Contains(fmt.Sprintf(template, args...), "%!")
+}
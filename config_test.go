@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeConfigFileTOML(t *testing.T) {
+	path := writeConfigFile(t, "cfg.toml", `
+# Tables, arrays of tables, multi-line and escaped strings
+[keywords]
+count = 12
+temperature = 0
+system_prompt = "Say \"hi\"\tplease"
+
+[search_terms]
+providers = ["Google", "google-vertex"]
+min_diversity = 0.7
+
+[[search_terms.fallbacks]]
+model = "moonshotai/kimi-k2-thinking"
+providers = ["Google"]
+
+[[search_terms.fallbacks]]
+model = "minimax/minimax-m2"
+
+[search_terms.prompts]
+refinement_system = """
+Line one
+Line two"""
+initial_system = '''C:\raw\path'''
+`)
+
+	cfg := defaultConfig()
+	if err := mergeConfigFile(&cfg, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Keywords.Count != 12 {
+		t.Errorf("keywords.count = %d, want 12", cfg.Keywords.Count)
+	}
+	if cfg.Keywords.Temperature == nil || *cfg.Keywords.Temperature != 0 {
+		t.Errorf("keywords.temperature = %v, want explicit 0", cfg.Keywords.Temperature)
+	}
+	if cfg.Keywords.SystemPrompt != "Say \"hi\"\tplease" {
+		t.Errorf("keywords.system_prompt = %q", cfg.Keywords.SystemPrompt)
+	}
+	if !reflect.DeepEqual(cfg.SearchTerms.Providers, []string{"Google", "google-vertex"}) {
+		t.Errorf("search_terms.providers = %v", cfg.SearchTerms.Providers)
+	}
+	if cfg.SearchTerms.MinDiversity != 0.7 {
+		t.Errorf("search_terms.min_diversity = %g, want 0.7", cfg.SearchTerms.MinDiversity)
+	}
+	if len(cfg.SearchTerms.Fallbacks) != 2 || cfg.SearchTerms.Fallbacks[1].Model != "minimax/minimax-m2" {
+		t.Errorf("search_terms.fallbacks = %+v", cfg.SearchTerms.Fallbacks)
+	}
+	if cfg.SearchTerms.Prompts.RefinementSystem != "Line one\nLine two" {
+		t.Errorf("refinement_system = %q", cfg.SearchTerms.Prompts.RefinementSystem)
+	}
+	if cfg.SearchTerms.Prompts.InitialSystem != `C:\raw\path` {
+		t.Errorf("initial_system = %q", cfg.SearchTerms.Prompts.InitialSystem)
+	}
+
+	// Untouched keys keep their defaults
+	if cfg.SearchTerms.Count != TARGET_SEARCH_TERM_COUNT {
+		t.Errorf("search_terms.count = %d, want default %d", cfg.SearchTerms.Count, TARGET_SEARCH_TERM_COUNT)
+	}
+}
+
+func TestMergeConfigFileErrors(t *testing.T) {
+	cases := []struct {
+		name, content, want string
+	}{
+		{"cfg.toml", "[keywords]\ncount = \n", "line 2"},
+		{"cfg.toml", "[keywords]\ncuont = 3\n", "unknown field"},
+		{"cfg.toml", "[keywords]\ncount = \"many\"\n", "count"},
+		{"cfg.json", `{"keywords": {"count": 3,}}`, "invalid character"},
+		{"cfg.yaml", "keywords: {}", "unsupported config format"},
+	}
+	for _, tc := range cases {
+		cfg := defaultConfig()
+		err := mergeConfigFile(&cfg, writeConfigFile(t, tc.name, tc.content))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %q: err = %v, want it to mention %q", tc.name, tc.content, err, tc.want)
+		}
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("NX_LANDER_KEYWORDS_COUNT", "11")
+	t.Setenv("NX_LANDER_KEYWORDS_TEMPERATURE", "0")
+	t.Setenv("NX_LANDER_KEYWORDS_PROVIDERS", "google-vertex, together")
+	t.Setenv("NX_LANDER_SEARCH_TERMS_MIN_DIVERSITY", "0.75")
+	t.Setenv("NX_LANDER_SEARCH_TERMS_PROMPTS_REFINEMENT_SYSTEM", "be brief")
+	t.Setenv("NX_LANDER_RETRY_JITTER", "0.5")
+
+	cfg := defaultConfig()
+	if err := applyEnvOverrides(reflect.ValueOf(&cfg).Elem(), CONFIG_ENV_PREFIX); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Keywords.Count != 11 {
+		t.Errorf("keywords.count = %d, want 11", cfg.Keywords.Count)
+	}
+	if cfg.Keywords.Temperature == nil || *cfg.Keywords.Temperature != 0 {
+		t.Errorf("keywords.temperature = %v, want explicit 0", cfg.Keywords.Temperature)
+	}
+	if !reflect.DeepEqual(cfg.Keywords.Providers, []string{"google-vertex", "together"}) {
+		t.Errorf("keywords.providers = %v", cfg.Keywords.Providers)
+	}
+	if cfg.SearchTerms.MinDiversity != 0.75 {
+		t.Errorf("search_terms.min_diversity = %g", cfg.SearchTerms.MinDiversity)
+	}
+	if cfg.SearchTerms.Prompts.RefinementSystem != "be brief" {
+		t.Errorf("refinement_system = %q", cfg.SearchTerms.Prompts.RefinementSystem)
+	}
+	if cfg.Retry.Jitter != 0.5 {
+		t.Errorf("retry.jitter = %g", cfg.Retry.Jitter)
+	}
+}
+
+func TestApplyEnvOverridesRejectsBadValues(t *testing.T) {
+	t.Setenv("NX_LANDER_SEARCH_TERMS_COUNT", "fifteen")
+
+	cfg := defaultConfig()
+	err := applyEnvOverrides(reflect.ValueOf(&cfg).Elem(), CONFIG_ENV_PREFIX)
+	if err == nil || !strings.Contains(err.Error(), "NX_LANDER_SEARCH_TERMS_COUNT") {
+		t.Errorf("err = %v, want it to name the variable", err)
+	}
+}
+
+func TestTemplateAccepts(t *testing.T) {
+	cases := []struct {
+		template string
+		args     []any
+		want     bool
+	}{
+		{"%d keywords for %s", []any{1, "theme"}, true},
+		{"%s keywords for %d", []any{1, "theme"}, false}, // Swapped verbs
+		{"%d keywords", []any{1, "theme"}, false},        // Too few verbs
+		{"%d %s %s", []any{1, "theme"}, false},           // Too many verbs
+		{"100%% sure: %d %s", []any{1, "theme"}, true},   // Escaped percent
+		{"", []any{}, false},
+		{KEYWORD_USER_PROMPT_TEMPLATE, []any{1, "theme"}, true},
+		{REFINEMENT_USER_PROMPT_TEMPLATE, []any{1, "theme", "terms", "missing", 1, 1}, true},
+	}
+	for _, tc := range cases {
+		if got := templateAccepts(tc.template, tc.args...); got != tc.want {
+			t.Errorf("templateAccepts(%q) = %v, want %v", tc.template, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	cfg := defaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Keywords.Temperature = float32Ptr(3)
+	cfg.SearchTerms.Count = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "keywords.temperature") || !strings.Contains(err.Error(), "search_terms.count") {
+		t.Errorf("err = %v, want both problems reported", err)
+	}
+}
+
+func TestExampleConfigLoadsAsDefaults(t *testing.T) {
+	cfg, err := loadConfig("nx-lander.example.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.SearchTerms.Scoring, defaultScoringWeights()) {
+		t.Errorf("example [search_terms.scoring] = %+v, want the defaults", cfg.SearchTerms.Scoring)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧹 DEDUPLICATION - One Query, One Term
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Two terms are duplicates when they normalise to the same query:
+//   "Best Romance Audiobooks" / "best romance audio books" / "best romance audiobook!"
+//     → case, punctuation, whitespace, plurals, spaces inside compounds
+//   "romance audiobooks for kids" / "kids romance audiobooks"
+//     → stop-words dropped, word order ignored
+// or when their normalised forms are within duplicate_similarity of each
+// other by edit distance (typos: "romance audiobooks for comute"). Typos
+// rarely hit a first letter, so words must keep their initials to match
+// fuzzily: "romance ebooks" / "romance books" stay two queries. Numbers are
+// never typos: "top 10 romance" / "top 15 romance" stay two queries.
+//
+// Stop-words are the locale's own (Locale.StopWords): "de" is "the" in Dutch
+// but a word worth keeping elsewhere.
+//
+// Duplicates lower the score, are listed in the refinement prompt as
+// "replace these", and are never part of the final set (later copies go).
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const DEFAULT_DUPLICATE_SIMILARITY = 0.9 // 1 - edit distance / length of the normalised forms
+
+// DEFAULT_STOP_WORDS - English words that never make two queries different
+var DEFAULT_STOP_WORDS = []string{"a", "an", "the", "to", "for", "of", "in", "on", "with", "and"}
+
+// defaultStopWordSet - English stop-words; used when a config was never resolved (tests, defaults)
+var defaultStopWordSet = stopWordSet(DEFAULT_STOP_WORDS)
+
+// stopWordSet - Lowercased words as a set
+func stopWordSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[strings.ToLower(word)] = true
+	}
+	return set
+}
+
+// TermDuplicate - A term that repeats an earlier one
+type TermDuplicate struct {
+	Term        string  `json:"term"`
+	DuplicateOf string  `json:"duplicate_of"`
+	Similarity  float64 `json:"similarity"` // 1 = same normalised query
+
+	index int // Position of Term in the deduped list, so repeated copies stay apart
+}
+
+// normalizedWords - Lowercase words without punctuation or stop-words
+func normalizedWords(term string, stopWords map[string]bool) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, term)
+
+	var words []string
+	for _, word := range strings.Fields(cleaned) {
+		if !stopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// termKeys - Two views of the normalised query:
+// bag = singular words in sorted order ("kids romance audiobooks" == "romance audiobooks for kids"),
+// compact = words joined in order, then singularised ("audio books" == "audiobooks")
+func termKeys(term string, stopWords map[string]bool) (bag, compact string) {
+	words := normalizedWords(term, stopWords)
+	singular := make([]string, len(words))
+	for i, word := range words {
+		singular[i] = singularize(word)
+	}
+	sort.Strings(singular)
+	return strings.Join(singular, " "), singularize(strings.Join(words, ""))
+}
+
+// findDuplicateTerms - Every term that repeats an earlier kept one, in order
+func findDuplicateTerms(terms []string, minSimilarity float64, stopWords map[string]bool) []TermDuplicate {
+	_, duplicates := dedupeTerms(terms, minSimilarity, stopWords)
+	return duplicates
+}
+
+// dedupeTerms - terms with later duplicates dropped (order kept), plus what was dropped
+func dedupeTerms(terms []string, minSimilarity float64, stopWords map[string]bool) ([]string, []TermDuplicate) {
+	type kept struct {
+		term, bag, compact string
+	}
+	var seen []kept
+	var duplicates []TermDuplicate
+	unique := make([]string, 0, len(terms))
+
+	for i, term := range terms {
+		bag, compact := termKeys(term, stopWords)
+		duplicate := false
+		for _, k := range seen {
+			similarity := 1.0
+			if bag != k.bag && compact != k.compact {
+				similarity = 0
+				if initials(bag) == initials(k.bag) && numbers(bag) == numbers(k.bag) {
+					similarity = max(stringSimilarity(bag, k.bag), stringSimilarity(compact, k.compact))
+				}
+			}
+			if similarity >= minSimilarity {
+				duplicates = append(duplicates, TermDuplicate{Term: term, DuplicateOf: k.term, Similarity: math.Round(similarity*1000) / 1000, index: i})
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			seen = append(seen, kept{term: term, bag: bag, compact: compact})
+			unique = append(unique, term)
+		}
+	}
+	return unique, duplicates
+}
+
+// initials - First letter of every word
+func initials(words string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(words) {
+		r := []rune(word)
+		b.WriteRune(r[0])
+	}
+	return b.String()
+}
+
+// numbers - The words with digits in them ("10", "1980s"), in order
+func numbers(words string) string {
+	var found []string
+	for _, word := range strings.Fields(words) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			found = append(found, word)
+		}
+	}
+	return strings.Join(found, " ")
+}
+
+// stringSimilarity - 1 - Levenshtein distance / longer length (runes)
+func stringSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
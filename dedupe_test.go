@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDedupeCatchesNormalisedAndFuzzyVariants(t *testing.T) {
+	terms := []string{
+		"best romance audiobooks",
+		"Best  Romance Audio-Books!", // case, punctuation, whitespace, split compound
+		"best romance audiobook",     // plural
+		"romance audiobooks for kids",
+		"kids romance audiobooks",        // stop-word and word order
+		"romance audiobooks for comute",  // typo of the next one, kept: it comes first
+		"romance audiobooks for commute", // typo match
+		"romance audiobooks for family",  // different query
+		"romance ebooks",
+		"romance books", // one letter off, but a different query
+	}
+
+	unique, duplicates := dedupeTerms(terms, DEFAULT_DUPLICATE_SIMILARITY, defaultStopWordSet)
+	want := []string{"best romance audiobooks", "romance audiobooks for kids", "romance audiobooks for comute", "romance audiobooks for family", "romance ebooks", "romance books"}
+	if strings.Join(unique, "|") != strings.Join(want, "|") {
+		t.Errorf("unique = %q, want %q", unique, want)
+	}
+	if len(duplicates) != 4 || duplicates[0].DuplicateOf != "best romance audiobooks" || duplicates[3].Similarity >= 1 {
+		t.Errorf("duplicates = %+v", duplicates)
+	}
+
+	// 1.0 turns fuzzy matching off; normalised matches still count
+	if _, exact := dedupeTerms(terms, 1, defaultStopWordSet); len(exact) != 3 {
+		t.Errorf("exact-only duplicates = %+v, want 3", exact)
+	}
+}
+
+func TestDuplicatesAreListedForRefinementAndDroppedFromResult(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	terms := append(append([]string(nil), cassetteRefinedTerms[:13]...), "Best Romance Audiobook", "unlimited romance audio books")
+
+	agent := NewSearchTermAgent("romance books", nil, nil, cfg.SearchTerms, nil)
+	agent.record(context.Background(), 0, USAGE_STAGE_INITIAL, terms, nil)
+	quality := agent.history[agent.best].Quality
+	if quality.Duplicates != 2 {
+		t.Fatalf("duplicates = %d (%+v), want 2", quality.Duplicates, quality.DuplicateTerms)
+	}
+
+	section := agent.identifyMissingPatterns(quality)
+	if !strings.Contains(section, "DUPLICATES - replace these") || !strings.Contains(section, `"unlimited romance audio books" (same search as "unlimited romance audiobooks")`) {
+		t.Errorf("refinement section does not ask to replace the duplicates:\n%s", section)
+	}
+
+	result := agent.Result()
+	if len(result.Terms) != 13 || strings.Join(result.Terms, "|") != strings.Join(cassetteRefinedTerms[:13], "|") {
+		t.Errorf("final terms = %q, want the 13 distinct ones", result.Terms)
+	}
+}
+
+func TestNumbersAndLocaleStopWordsKeepQueriesApart(t *testing.T) {
+	nl := defaultConfig()
+	nl.Locale = "nl"
+	if err := nl.resolveLocale(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		a, b      string
+		stopWords map[string]bool
+		duplicate bool
+	}{
+		{"different numbers", "top 10 romance audiobooks", "top 15 romance audiobooks", defaultStopWordSet, false},
+		{"same number with a typo", "top 10 romance audiobooks", "top 10 romance audiobboks", defaultStopWordSet, true},
+		{"dutch article under nl", "de beste romans", "beste romans", nl.SearchTerms.stopWordSet(), true},
+		{"dutch article under en", "de beste romans", "beste romans", defaultStopWordSet, false},
+		{"english article under nl", "the romance books", "romance books", nl.SearchTerms.stopWordSet(), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, duplicates := dedupeTerms([]string{tc.a, tc.b}, DEFAULT_DUPLICATE_SIMILARITY, tc.stopWords)
+			if got := len(duplicates) == 1; got != tc.duplicate {
+				t.Errorf("duplicate = %v (%+v), want %v", got, duplicates, tc.duplicate)
+			}
+		})
+	}
+}
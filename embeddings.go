@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧭 EMBEDDINGS - Semantic Diversity and Near-Duplicate Detection
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Word-ratio diversity rewards synonyms ("audiobooks" / "audio books") and
+// punishes the theme word every term legitimately repeats. Here each term
+// becomes a vector and diversity is 1 - mean pairwise cosine similarity.
+// Pairs above near_duplicate_threshold are flagged in the refinement prompt.
+//
+// The Embedder is pluggable (EMBEDDERS, [search_terms.embeddings] embedder);
+// the built-in "hashed" one is pure Go and offline: character n-grams hashed
+// into a fixed-size vector. Cheap, deterministic, good at spelling variants.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	EMBEDDER_HASHED                  = "hashed"
+	DEFAULT_EMBEDDING_DIMENSIONS     = 512
+	DEFAULT_EMBEDDING_NGRAM          = 3
+	DEFAULT_NEAR_DUPLICATE_THRESHOLD = 0.85 // "audiobooks"/"audio books" ≈ 0.9, "for commute"/"for family" ≈ 0.75
+)
+
+// Embedder - Turns texts into vectors; one vector per text, same order
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// EmbeddingConfig - [search_terms.embeddings]
+type EmbeddingConfig struct {
+	Embedder               string  `json:"embedder"` // Key in EMBEDDERS; "" = word-ratio diversity only
+	Dimensions             int     `json:"dimensions"`
+	NGram                  int     `json:"ngram"`
+	NearDuplicateThreshold float64 `json:"near_duplicate_threshold"` // Cosine similarity
+}
+
+func defaultEmbeddingConfig() EmbeddingConfig {
+	return EmbeddingConfig{
+		Embedder:               EMBEDDER_HASHED,
+		Dimensions:             DEFAULT_EMBEDDING_DIMENSIONS,
+		NGram:                  DEFAULT_EMBEDDING_NGRAM,
+		NearDuplicateThreshold: DEFAULT_NEAR_DUPLICATE_THRESHOLD,
+	}
+}
+
+// EMBEDDERS - Embedder constructors by name
+var EMBEDDERS = map[string]func(EmbeddingConfig) Embedder{
+	EMBEDDER_HASHED: func(cfg EmbeddingConfig) Embedder {
+		return &HashedNGramEmbedder{Dimensions: cfg.Dimensions, N: cfg.NGram}
+	},
+}
+
+// New - The configured embedder, nil when embeddings are off
+func (c EmbeddingConfig) New() (Embedder, error) {
+	if c.Embedder == "" {
+		return nil, nil
+	}
+	build, ok := EMBEDDERS[c.Embedder]
+	if !ok {
+		names := make([]string, 0, len(EMBEDDERS))
+		for name := range EMBEDDERS {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown embedder %q (known: %s)", c.Embedder, strings.Join(names, ", "))
+	}
+	return build(c), nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// #️⃣ HASHED N-GRAM EMBEDDER
+// ═══════════════════════════════════════════════════════════════════════════
+
+// HashedNGramEmbedder - Character n-grams of each word (padded with spaces), FNV-hashed
+// into Dimensions buckets with a sign bit, then L2-normalised
+type HashedNGramEmbedder struct {
+	Dimensions int
+	N          int
+}
+
+func (e *HashedNGramEmbedder) Name() string {
+	return fmt.Sprintf("%s-%dgram-%d", EMBEDDER_HASHED, e.N, e.Dimensions)
+}
+
+func (e *HashedNGramEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashedNGramEmbedder) embed(text string) []float64 {
+	vector := make([]float64, e.Dimensions)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		runes := []rune(" " + word + " ")
+		for start := 0; start+e.N <= len(runes); start++ {
+			h := fnv.New64a()
+			h.Write([]byte(string(runes[start : start+e.N])))
+			sum := h.Sum64()
+			sign := 1.0
+			if sum&1 == 1 {
+				sign = -1
+			}
+			vector[(sum>>1)%uint64(e.Dimensions)] += sign
+		}
+	}
+	return normalize(vector)
+}
+
+func normalize(v []float64) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return v
+	}
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+// cosine - Dot product of two normalised vectors
+func cosine(a, b []float64) float64 {
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🗃️ CACHED EMBEDDER
+// ═══════════════════════════════════════════════════════════════════════════
+
+// CachedEmbedder - Embeds each distinct text once; for scoring many subsets of the same terms
+type CachedEmbedder struct {
+	inner   Embedder
+	vectors map[string][]float64
+}
+
+// NewCachedEmbedder wraps inner; nil stays nil (embeddings off)
+func NewCachedEmbedder(inner Embedder) Embedder {
+	if inner == nil {
+		return nil
+	}
+	return &CachedEmbedder{inner: inner, vectors: make(map[string][]float64)}
+}
+
+func (e *CachedEmbedder) Name() string {
+	return e.inner.Name()
+}
+
+func (e *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var missing []string
+	for _, text := range texts {
+		if _, ok := e.vectors[text]; !ok {
+			missing = append(missing, text)
+		}
+	}
+	if len(missing) > 0 {
+		vectors, err := e.inner.Embed(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(missing) {
+			return nil, fmt.Errorf("%d vectors for %d texts", len(vectors), len(missing))
+		}
+		for i, text := range missing {
+			e.vectors[text] = vectors[i]
+		}
+	}
+
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.vectors[text]
+	}
+	return vectors, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📐 SEMANTIC METRICS
+// ═══════════════════════════════════════════════════════════════════════════
+
+// NearDuplicate - Two terms that say (almost) the same thing
+type NearDuplicate struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"`
+}
+
+// semanticDiversity - 1 - mean pairwise cosine (0 = all the same, 1 = unrelated),
+// plus every pair at or above threshold, most similar first
+func semanticDiversity(ctx context.Context, embedder Embedder, terms []string, threshold float64) (float64, []NearDuplicate, error) {
+	if len(terms) < 2 {
+		return 1, nil, nil
+	}
+	vectors, err := embedder.Embed(ctx, terms)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", embedder.Name(), err)
+	}
+	if len(vectors) != len(terms) {
+		return 0, nil, fmt.Errorf("%s: %d vectors for %d terms", embedder.Name(), len(vectors), len(terms))
+	}
+
+	var total float64
+	var pairs int
+	var duplicates []NearDuplicate
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			similarity := cosine(vectors[i], vectors[j])
+			total += similarity
+			pairs++
+			if similarity >= threshold {
+				duplicates = append(duplicates, NearDuplicate{A: terms[i], B: terms[j], Similarity: math.Round(similarity*1000) / 1000})
+			}
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Similarity > duplicates[j].Similarity })
+
+	diversity := math.Max(0, math.Min(1, 1-total/float64(pairs)))
+	return diversity, duplicates, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingEmbedder struct{}
+
+func (failingEmbedder) Name() string { return "failing" }
+
+func (failingEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return nil, errors.New("model offline")
+}
+
+func TestSemanticDiversityFlagsSpellingVariants(t *testing.T) {
+	cfg := defaultConfig().SearchTerms
+	embedder, err := cfg.Embeddings.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same theme word everywhere, but every term is a different angle
+	varied := []string{"romance audiobooks for commute", "free romance ebook trial", "romance series like bridgerton", "where to listen to romance offline"}
+	// Different words, same query
+	variants := []string{"best romance audiobooks", "Best Romance Audio Books", "best romance audiobook", "romance audiobooks for commute"}
+
+	a := assessSearchTerms(context.Background(), varied, "romance books", cfg, embedder)
+	b := assessSearchTerms(context.Background(), variants, "romance books", cfg, embedder)
+	if a.SemanticDiversity <= b.SemanticDiversity {
+		t.Errorf("semantic diversity: varied %.3f <= variants %.3f", a.SemanticDiversity, b.SemanticDiversity)
+	}
+	if len(a.NearDuplicates) != 0 {
+		t.Errorf("varied set flagged near-duplicates: %+v", a.NearDuplicates)
+	}
+	if len(b.NearDuplicates) < 2 || b.NearDuplicates[0].A != "best romance audiobooks" {
+		t.Errorf("variants: near-duplicates %+v, want both respellings of the first term", b.NearDuplicates)
+	}
+
+	// Respellings are also exact duplicates after normalisation, so they are listed once, to replace
+	agent := NewSearchTermAgent("romance books", nil, nil, cfg, nil)
+	section := agent.identifyMissingPatterns(b)
+	if !strings.Contains(section, `"Best Romance Audio Books" (same search as "best romance audiobooks")`) || strings.Contains(section, "NEAR-DUPLICATES") {
+		t.Errorf("refinement section does not flag the pair once:\n%s", section)
+	}
+}
+
+// countingEmbedder - The hashed embedder, counting every text it is asked to embed
+type countingEmbedder struct {
+	HashedNGramEmbedder
+	texts int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	e.texts += len(texts)
+	return e.HashedNGramEmbedder.Embed(ctx, texts)
+}
+
+func TestFailingEmbedderFallsBackToWordDiversity(t *testing.T) {
+	cfg := defaultConfig().SearchTerms
+	quality := assessSearchTerms(context.Background(), cassetteRefinedTerms, "romance books", cfg, failingEmbedder{})
+	if quality.SemanticDiversity != quality.DiversityScore || quality.NearDuplicates != nil {
+		t.Errorf("semantic %.3f / word %.3f, near-duplicates %v: want the word ratio and none",
+			quality.SemanticDiversity, quality.DiversityScore, quality.NearDuplicates)
+	}
+}
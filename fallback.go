@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧰 OFFLINE FALLBACK - Deterministic Template-Based Generation
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Used when OPENROUTER_API_KEY is missing (CI, laptops without a key).
+// Expands the theme across the same axes the prompts describe:
+// formats, intent signals, value propositions and use cases - in the run's
+// locale, so offline markets runs still produce native, distinct terms.
+// Same theme in = same keywords and search terms out. No network, no randomness.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// FallbackTemplates - One locale's offline vocabulary. Keywords and SearchTerms are filled in per theme:
+// {theme} as given, {books}/{audiobooks}/{ebooks}/{magazines} the theme in that format (withFormat)
+type FallbackTemplates struct {
+	Books       string   // The generic format word: "books", "böcker"
+	Formats     []string // Audiobooks, ebooks, magazines - in KEYWORD_ANGLE_IDS "format" order
+	FormatWords []string // Words that already name a format: "romance books" becomes "romance audiobooks", not "romance books audiobooks"
+	Intents     []string
+	ValueProps  []string
+	UseCases    []string
+	Keywords    []string // Hand-picked mix, ahead of the axis cross product
+	SearchTerms []string // One per pattern family first, so even short lists cover everything
+}
+
+// DEFAULT_FALLBACK_TEMPLATES - English; locales.go has one per locale
+var DEFAULT_FALLBACK_TEMPLATES = FallbackTemplates{
+	Books:       "books",
+	Formats:     []string{"audiobooks", "ebooks", "magazines"},
+	FormatWords: []string{"audiobooks", "audiobook", "ebooks", "ebook", "books", "book", "novels", "novel", "magazines", "magazine"},
+	Intents:     []string{"best", "top", "popular", "trending"},
+	ValueProps:  []string{"unlimited", "free trial", "streaming", "family"},
+	UseCases:    []string{"for commute", "for family", "for kids", "for beginners"},
+	Keywords: []string{
+		"{audiobooks}",
+		"{ebooks}",
+		"best {audiobooks}",
+		"popular {theme} recommendations",
+		"unlimited {audiobooks}",
+		"{audiobooks} free trial",
+		"{audiobooks} for commute",
+		"trending {ebooks}",
+	},
+	SearchTerms: []string{
+		"best new {audiobooks}",                   // Best/Top
+		"where to stream {theme} online",          // Question
+		"{theme} streaming vs buying",             // Comparison
+		"free {ebooks} trial",                     // Value
+		"{theme} for commute",                     // User intent
+		"{magazines} subscription",                // Format mix
+		"top rated {theme} series",                // Best/Top
+		"how to get {theme} cheaply",              // Question
+		"{theme} subscription comparison",         // Comparison
+		"affordable {theme} streaming plan",       // Value
+		"which {theme} app has offline listening", // Question
+		"{audiobooks} narrated by famous actors",  // Format mix
+		"family sharing {theme} library",          // Value
+		"{theme} bedtime stories kids love",       // Use case
+		"most popular {theme} this month",         // Best/Top
+	},
+}
+
+// fallbackFiller - Replaces the template placeholders for one theme
+func fallbackFiller(base string, t FallbackTemplates) *strings.Replacer {
+	return strings.NewReplacer(
+		"{theme}", base,
+		"{books}", withFormat(base, t.Books, t),
+		"{audiobooks}", withFormat(base, t.Formats[0], t),
+		"{ebooks}", withFormat(base, t.Formats[1], t),
+		"{magazines}", withFormat(base, t.Formats[2], t),
+	)
+}
+
+// withFormat - The theme in one format: swaps the theme's own format word (the locale's or
+// an English one - ideas are often typed in English), or appends the format
+func withFormat(base, format string, t FallbackTemplates) string {
+	words := strings.Fields(base)
+	for i := len(words) - 1; i >= 0; i-- {
+		if slices.Contains(t.FormatWords, words[i]) || slices.Contains(DEFAULT_FALLBACK_TEMPLATES.FormatWords, words[i]) {
+			words[i] = format
+			return strings.Join(words, " ")
+		}
+	}
+	return base + " " + format
+}
+
+// generateFallbackKeywords - Template expansion of the theme in the locale's language, same shape as generateKeywords
+func generateFallbackKeywords(theme string, count int, locale Locale) []string {
+	base := strings.ToLower(strings.TrimSpace(theme))
+	t := locale.Fallback
+	fill := fallbackFiller(base, t)
+
+	// Hand-picked mix first, then the full axis cross product for larger counts
+	var candidates []string
+	for _, template := range t.Keywords {
+		candidates = append(candidates, fill.Replace(template))
+	}
+	for _, format := range t.Formats {
+		formatted := withFormat(base, format, t)
+		for _, intent := range t.Intents {
+			candidates = append(candidates, fmt.Sprintf("%s %s", intent, formatted))
+		}
+		for _, value := range t.ValueProps {
+			candidates = append(candidates, fmt.Sprintf("%s %s", value, formatted))
+		}
+		for _, useCase := range t.UseCases {
+			candidates = append(candidates, fmt.Sprintf("%s %s", formatted, useCase))
+		}
+	}
+
+	if count <= 0 {
+		count = KEYWORD_COUNT
+	}
+	keywords := uniqueStrings(candidates)
+	if len(keywords) > count {
+		keywords = keywords[:count]
+	}
+	if len(keywords) < count {
+		log.Printf("⚠️  Fallback templates only produce %d of %d requested keywords", len(keywords), count)
+	}
+
+	// Same contract as generateKeywords: the theme itself is always the last keyword
+	keywords = append(keywords, base)
+	log.Printf("🧰 Fallback generated %d keywords (%s)", len(keywords), locale.Code)
+	return keywords
+}
+
+// generateFallbackSearchTerms - Template expansion covering all six pattern families of the locale.
+// Each template adds its own vocabulary, so a two-word theme clears MIN_DIVERSITY_SCORE;
+// longer themes repeat more words per term and score lower.
+func generateFallbackSearchTerms(theme string, keywords []string, count int, locale Locale) []string {
+	base := strings.ToLower(strings.TrimSpace(theme))
+	t := locale.Fallback
+	fill := fallbackFiller(base, t)
+
+	var candidates []string
+	for _, template := range t.SearchTerms {
+		candidates = append(candidates, fill.Replace(template))
+	}
+
+	// Top up from the keywords when more terms are requested than we have templates
+	for _, kw := range keywords {
+		candidates = append(candidates, fmt.Sprintf("%s %s", strings.ToLower(kw), t.UseCases[1]))
+	}
+
+	if count <= 0 {
+		count = TARGET_SEARCH_TERM_COUNT
+	}
+	terms := uniqueStrings(candidates)
+	if len(terms) > count {
+		terms = terms[:count]
+	}
+	if len(terms) < count {
+		log.Printf("⚠️  Fallback templates only produce %d of %d requested search terms", len(terms), count)
+	}
+
+	log.Printf("🧰 Fallback generated %d search terms (%s)", len(terms), locale.Code)
+	return terms
+}
+
+// uniqueStrings - Order-preserving dedup (case-insensitive, whitespace-trimmed)
+func uniqueStrings(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range items {
+		key := strings.ToLower(strings.Join(strings.Fields(item), " "))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, strings.Join(strings.Fields(item), " "))
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFallbackMeetsQualityBarInEveryLocale(t *testing.T) {
+	for _, code := range localeCodes() {
+		cfg := defaultConfig()
+		cfg.Locale = code
+		if err := cfg.resolveLocale(); err != nil {
+			t.Fatal(err)
+		}
+		agent := &SearchTermAgent{cfg: cfg.SearchTerms}
+
+		for _, theme := range []string{"romance books", "thriller audiobooks", "fantasy"} {
+			keywords := generateFallbackKeywords(theme, cfg.Keywords.Count, localeFor(cfg.Locale))
+			if quality := evaluateKeywordQuality(keywords, theme, cfg.Keywords); quality.AngleCoverage != 1 {
+				t.Errorf("%s %q: fallback keywords miss angles: %+v", code, theme, quality.Angles)
+			}
+
+			terms := generateFallbackSearchTerms(theme, keywords, cfg.SearchTerms.Count, localeFor(cfg.Locale))
+			quality := evaluateSearchTermQuality(terms, theme, cfg.SearchTerms)
+			candidate := SearchTermCandidate{Terms: terms, Quality: quality, Score: cfg.SearchTerms.Scoring.Score(quality, cfg.SearchTerms.Count)}
+			if !agent.targetReached(candidate) {
+				t.Errorf("%s %q: fallback terms score %.1f, below target: %+v", code, theme, candidate.Score, quality)
+			}
+		}
+	}
+}
+
+func TestFallbackKeywordsHonourCount(t *testing.T) {
+	for _, count := range []int{1, 8, 12, 30} {
+		keywords := generateFallbackKeywords("romance books", count, LOCALES[DEFAULT_LOCALE])
+		if got := len(keywords); got != count+1 { // +1: the theme itself
+			t.Errorf("count %d: got %d keywords, want %d", count, got, count+1)
+		}
+		if keywords[len(keywords)-1] != "romance books" {
+			t.Errorf("count %d: last keyword %q, want the theme", count, keywords[len(keywords)-1])
+		}
+	}
+}
+
+func TestFallbackIsDeterministic(t *testing.T) {
+	a := generateFallbackSearchTerms("Romance Books", nil, 15, LOCALES[DEFAULT_LOCALE])
+	b := generateFallbackSearchTerms("romance books ", nil, 15, LOCALES[DEFAULT_LOCALE])
+	if len(a) != len(b) {
+		t.Fatalf("lengths differ: %d vs %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("term %d differs: %q vs %q", i, a[i], b[i])
+		}
+	}
+}
+
+func TestFallbackSwapsFormatInsteadOfStacking(t *testing.T) {
+	theme, locale := "romance books", LOCALES[DEFAULT_LOCALE]
+	terms := append(generateFallbackKeywords(theme, 30, locale), generateFallbackSearchTerms(theme, nil, 15, locale)...)
+	for _, term := range terms {
+		for _, bad := range []string{"books audiobooks", "books ebook", "books magazines", "2025", "kindle", "audible"} {
+			if strings.Contains(term, bad) {
+				t.Errorf("%q contains %q", term, bad)
+			}
+		}
+	}
+	if got := withFormat("thriller", "audiobooks", locale.Fallback); got != "thriller audiobooks" {
+		t.Errorf("withFormat appends to a bare theme: got %q", got)
+	}
+	if got := withFormat("romance books", "ljudböcker", LOCALES["sv"].Fallback); got != "romance ljudböcker" {
+		t.Errorf("withFormat swaps an English format word in other locales: got %q", got)
+	}
+}
@@ -2,4 +2,7 @@ module nx-lander-agent
 
 go 1.25.4
 
-require github.com/revrost/go-openrouter v1.0.2
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/revrost/go-openrouter v1.0.2
+)
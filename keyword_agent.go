@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🔑 KEYWORD SPECIALIST - Seed Keywords That Actually Cover the Angles
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Same shape as the SearchTermAgent, smaller job:
+// - 1 initial call + up to max_refinement_iterations stateless refinements
+// - Quality is checked locally: exact count, no duplicates, and coverage of
+//   the four keyword angles (format, intent, value, use case)
+// - The angle vocabulary is the locale's KeywordAngles prompt lines, so what
+//   the prompt asks for and what the check looks for never drift apart
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	KEYWORD_MAX_REFINEMENT_ITERATIONS = 2    // Total: 1 initial + 2 refinements = 3 calls max
+	KEYWORD_MIN_ANGLE_COVERAGE        = 3    // Out of the 4 angles
+	KEYWORD_MIN_THEME_RELEVANCE       = 0.75 // Share of keywords sharing a content word with the theme
+)
+
+// Keyword stage status - Why keyword refinement stopped
+const (
+	KEYWORD_STATUS_TARGET_REACHED    = "target_reached"
+	KEYWORD_STATUS_MAX_ITERATIONS    = "max_iterations"
+	KEYWORD_STATUS_REFINEMENT_FAILED = "refinement_failed"
+	KEYWORD_STATUS_BUDGET_EXHAUSTED  = "budget_exhausted"
+	KEYWORD_STATUS_TIMED_OUT         = "timed_out" // The stage deadline ran out; fallback keywords when nothing came back
+	KEYWORD_STATUS_OFFLINE           = "offline"   // Fallback templates, no agent
+)
+
+// KEYWORD_ANGLE_IDS - One per KeywordAngles line, in order
+var KEYWORD_ANGLE_IDS = []string{"format", "intent", "value", "use_case"}
+
+// DEFAULT_KEYWORD_ANGLES - The English angle lines (same as in KEYWORD_USER_PROMPT_TEMPLATE)
+var DEFAULT_KEYWORD_ANGLES = []string{
+	"- Format variations: audiobooks, ebooks, magazines",
+	"- Intent signals: best, top, popular, trending, recommendations",
+	"- Value propositions: unlimited, family, streaming, free trial",
+	"- Use cases: for commute, for family, for kids",
+}
+
+var defaultKeywordAngleSet = mustPatternSet(keywordAngleRules(DEFAULT_KEYWORD_ANGLES), nil)
+
+// keywordAngleRules - "- Label: a, b, c" lines as keyword rules; each item also in its
+// singular form, so "audiobook" matches too (the plural alone would miss it)
+func keywordAngleRules(lines []string) []PatternRule {
+	var rules []PatternRule
+	for i, line := range lines {
+		label, items, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "- "), ":")
+		if !ok || i >= len(KEYWORD_ANGLE_IDS) {
+			continue
+		}
+		rule := PatternRule{ID: KEYWORD_ANGLE_IDS[i], Description: label, Example: strings.TrimSpace(items)}
+		for _, item := range strings.Split(items, ",") {
+			words := strings.Fields(strings.ToLower(item))
+			if len(words) == 0 {
+				continue
+			}
+			plain := strings.Join(words, " ")
+			for j, word := range words {
+				words[j] = singularize(word)
+			}
+			rule.Keywords = append(rule.Keywords, plain)
+			if singular := strings.Join(words, " "); singular != plain {
+				rule.Keywords = append(rule.Keywords, singular)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// KeywordAgent - The keyword craftsman
+type KeywordAgent struct {
+	theme   string
+	backend LLMBackend
+	cfg     KeywordStageConfig
+	budget  *Budget // nil = only MaxRefinementIterations limits the loop
+
+	// Current state (current is always the best candidate so far)
+	current    []string
+	quality    KeywordQuality
+	iteration  int
+	apiCalls   int
+	status     string
+	stopReason string
+}
+
+// KeywordQuality - Local checks on one keyword set (the theme itself is not counted)
+type KeywordQuality struct {
+	Angles         map[string]bool `json:"angles"`         // Angle id → covered by some keyword
+	AngleCoverage  float64         `json:"angle_coverage"` // Covered share of the angles
+	Duplicates     int             `json:"duplicates"`
+	DuplicateTerms []TermDuplicate `json:"duplicate_terms,omitempty"`
+	ThemeRelevance float64         `json:"theme_relevance"`
+	Count          int             `json:"count"` // Distinct keywords
+}
+
+// KeywordResult - What a Generate run produced, beyond the keywords themselves
+type KeywordResult struct {
+	Keywords   []string       `json:"keywords"` // Theme last, as always
+	Quality    KeywordQuality `json:"quality"`
+	Iterations int            `json:"iterations"` // Refinement calls that returned keywords
+	APICalls   int            `json:"api_calls"`
+	Status     string         `json:"status"` // KEYWORD_STATUS_*
+	StopReason string         `json:"stop_reason,omitempty"`
+}
+
+// NewKeywordAgent creates a new keyword generator
+func NewKeywordAgent(theme string, backend LLMBackend, cfg KeywordStageConfig, budget *Budget) *KeywordAgent {
+	return &KeywordAgent{theme: theme, backend: backend, cfg: cfg, budget: budget}
+}
+
+// Generate - 1 initial call, then refinements until the checks pass
+func (a *KeywordAgent) Generate(ctx context.Context) ([]string, error) {
+	keywords, err := a.call(withUsageStage(ctx, USAGE_STAGE_KEYWORDS),
+		fmt.Sprintf(a.cfg.UserPromptTemplate, a.cfg.Count, a.theme))
+	if err != nil {
+		return nil, err
+	}
+	a.record(keywords)
+
+	a.status = KEYWORD_STATUS_MAX_ITERATIONS
+	for a.iteration < a.cfg.MaxRefinementIterations {
+		issues := a.issues(a.quality)
+		if len(issues) == 0 {
+			a.status = KEYWORD_STATUS_TARGET_REACHED
+			break
+		}
+		if ctx.Err() != nil {
+			a.status, a.stopReason = KEYWORD_STATUS_TIMED_OUT, ctx.Err().Error()
+			break
+		}
+		if err := a.budget.CheckNextCall(); err != nil {
+			log.Printf("💸 Stopping keyword refinement, %v", err)
+			a.status, a.stopReason = KEYWORD_STATUS_BUDGET_EXHAUSTED, err.Error()
+			break
+		}
+
+		log.Printf("🔄 Keyword refinement %d: %d issues", a.iteration+1, len(issues))
+		stage := fmt.Sprintf(USAGE_STAGE_KEYWORD_REFINEMENT_PATTERN, a.iteration+1)
+		refined, err := a.call(withUsageStage(ctx, stage), fmt.Sprintf(a.cfg.RefinementUserTemplate,
+			len(a.current), a.theme, strings.Join(a.current, "\n"), strings.Join(issues, "\n"), a.cfg.Count))
+		if err != nil {
+			log.Printf("⚠️  Keyword refinement %d failed, keeping current keywords: %v", a.iteration+1, err)
+			a.status, a.stopReason = KEYWORD_STATUS_REFINEMENT_FAILED, err.Error()
+			break
+		}
+		a.iteration++
+		a.record(refined)
+	}
+	if a.status == KEYWORD_STATUS_MAX_ITERATIONS && len(a.issues(a.quality)) == 0 {
+		a.status = KEYWORD_STATUS_TARGET_REACHED
+	}
+
+	keywords = withThemeLast(a.current, a.theme, a.cfg)
+	log.Printf("✨ Generated %d keywords (%s) after %d API calls", len(keywords), a.status, a.apiCalls)
+	return keywords, nil
+}
+
+// Result - Final keywords with their quality and call accounting (call after Generate)
+func (a *KeywordAgent) Result() KeywordResult {
+	return KeywordResult{
+		Keywords:   withThemeLast(a.current, a.theme, a.cfg),
+		Quality:    a.quality,
+		Iterations: a.iteration,
+		APICalls:   a.apiCalls,
+		Status:     a.status,
+		StopReason: a.stopReason,
+	}
+}
+
+// call - One stateless keyword call; fenced/truncated/plain-text answers are repaired
+func (a *KeywordAgent) call(ctx context.Context, userPrompt string) ([]string, error) {
+	resp, err := a.backend.ChatWithTools(ctx, ChatRequest{
+		Model:        a.cfg.Model,
+		Providers:    a.cfg.Providers,
+		SystemPrompt: a.cfg.SystemPrompt,
+		UserPrompt:   userPrompt,
+		Tools:        a.keywordTool(),
+		Temperature:  a.cfg.Spec.Temperature(a.cfg.Temperature),
+	})
+	a.apiCalls++
+	if err != nil {
+		return nil, fmt.Errorf("AI failed: %w", err)
+	}
+
+	list, err := repairToolList(resp, "keywords")
+	if err != nil {
+		return nil, fmt.Errorf("no valid tool call: %w", err)
+	}
+	if len(list.Repairs) > 0 {
+		log.Printf("🩹 Repaired keyword response (%s)", strings.Join(list.Repairs, ", "))
+	}
+	return list.Items, nil
+}
+
+// record - Keeps keywords when they have fewer issues than the current set (ties keep the earlier)
+func (a *KeywordAgent) record(keywords []string) {
+	quality := evaluateKeywordQuality(keywords, a.theme, a.cfg)
+	if a.current != nil && len(a.issues(quality)) >= len(a.issues(a.quality)) {
+		log.Printf("📉 Keyword refinement %d did not fix anything, keeping the previous set", a.iteration)
+		return
+	}
+	a.current, a.quality = keywords, quality
+}
+
+// issues - What's wrong with a keyword set, one prompt line each (empty = target met)
+func (a *KeywordAgent) issues(quality KeywordQuality) []string {
+	var issues []string
+	if quality.Count != a.cfg.Count {
+		issues = append(issues, fmt.Sprintf("- Wrong count: %d distinct keywords, need EXACTLY %d", quality.Count, a.cfg.Count))
+	}
+	covered := 0
+	for _, ok := range quality.Angles {
+		if ok {
+			covered++
+		}
+	}
+	if covered < a.cfg.MinAngleCoverage {
+		for _, line := range a.cfg.angleSet().DescribeMissing(quality.Angles) {
+			issues = append(issues, "- Missing angle: "+strings.TrimPrefix(line, "- "))
+		}
+	}
+	if quality.ThemeRelevance < a.cfg.MinThemeRelevance {
+		issues = append(issues, fmt.Sprintf("- Off theme: only %.0f%% of the keywords mention %q, need at least %.0f%%",
+			quality.ThemeRelevance*100, a.theme, a.cfg.MinThemeRelevance*100))
+	}
+	for _, d := range quality.DuplicateTerms {
+		issues = append(issues, fmt.Sprintf("- Duplicate: %q is the same as %q, replace it", d.Term, d.DuplicateOf))
+	}
+	return issues
+}
+
+func (a *KeywordAgent) keywordTool() []ToolDefinition {
+	return []ToolDefinition{
+		{
+			Name:        "submit_keywords",
+			Description: "Submit the generated SEO keywords",
+			Parameters: json.RawMessage(fmt.Sprintf(`{
+				"type": "object",
+				"properties": {
+					"keywords": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Array of %d SEO keywords"
+					}
+				},
+				"required": ["keywords"]
+			}`, a.cfg.Count)),
+		},
+	}
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📊 QUALITY EVALUATION - Local Logic (NO API CALLS!)
+// ═══════════════════════════════════════════════════════════════════════════
+
+// evaluateKeywordQuality - Angle coverage, duplicates and relevance; copies of the theme are ignored
+func evaluateKeywordQuality(keywords []string, theme string, cfg KeywordStageConfig) KeywordQuality {
+	var own []string
+	themeBag, _ := termKeys(theme, cfg.stopWordSet())
+	for _, keyword := range keywords {
+		if bag, _ := termKeys(keyword, cfg.stopWordSet()); bag != themeBag {
+			own = append(own, keyword)
+		}
+	}
+
+	var quality KeywordQuality
+	lower := make([]string, len(own))
+	for i, keyword := range own {
+		lower[i] = strings.ToLower(keyword)
+	}
+	quality.Angles, quality.AngleCoverage = cfg.angleSet().Detect(lower)
+	quality.DuplicateTerms = findDuplicateTerms(own, DEFAULT_DUPLICATE_SIMILARITY, cfg.stopWordSet())
+	quality.Duplicates = len(quality.DuplicateTerms)
+	quality.ThemeRelevance = themeRelevance(own, theme)
+	quality.Count = len(own) - quality.Duplicates
+	return quality
+}
+
+// withThemeLast - The first cfg.Count keywords without duplicates or copies of the theme, then the theme itself
+func withThemeLast(keywords []string, theme string, cfg KeywordStageConfig) []string {
+	theme = strings.ToLower(strings.TrimSpace(theme))
+	unique, _ := dedupeTerms(append([]string{theme}, keywords...), DEFAULT_DUPLICATE_SIMILARITY, cfg.stopWordSet())
+	return append(firstN(unique[1:], cfg.Count), theme)
+}
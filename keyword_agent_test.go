@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestKeywordQualityChecksCountAnglesAndDuplicates(t *testing.T) {
+	cfg := defaultConfig().Keywords
+
+	fallback := evaluateKeywordQuality(generateFallbackKeywords("romance books", cfg.Count, LOCALES[DEFAULT_LOCALE]), "romance books", cfg)
+	if fallback.Count != cfg.Count || fallback.AngleCoverage != 1 || fallback.Duplicates != 0 {
+		t.Errorf("fallback keywords: %+v, want %d distinct covering every angle", fallback, cfg.Count)
+	}
+
+	sloppy := evaluateKeywordQuality([]string{"romance audiobooks", "Romance Audio Books", "best romance novels", "romance books"}, "romance books", cfg)
+	if sloppy.Count != 2 || sloppy.Duplicates != 1 || sloppy.Angles["value"] || !sloppy.Angles["format"] || !sloppy.Angles["intent"] {
+		t.Errorf("sloppy keywords: %+v", sloppy)
+	}
+
+	// Angle vocabulary follows the locale
+	full := defaultConfig()
+	full.Locale = "sv"
+	if err := full.resolveLocale(); err != nil {
+		t.Fatal(err)
+	}
+	swedish := evaluateKeywordQuality([]string{"romance ljudböcker", "bästa romance", "romance gratis provperiod", "romance för pendling"}, "romance", full.Keywords)
+	if swedish.AngleCoverage != 1 {
+		t.Errorf("swedish angles = %v, want all four", swedish.Angles)
+	}
+}
+
+func TestKeywordAgentRefinesUntilTargetsMet(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	good := []string{
+		"romance audiobooks", "romance ebooks", "best romance audiobooks", "popular romance recommendations",
+		"unlimited romance audiobooks", "romance audiobooks free trial", "romance audiobooks for commute", "trending romance ebooks",
+	}
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: []string{"romance audiobooks", "romance audio books", "best romance ebooks", "romance books"}},
+		{terms: append([]string{"Romance Books"}, good...)},
+	}}
+
+	result, err := generateKeywords(context.Background(), backend, "Romance Books", cfg.Keywords, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.requests) != 2 || result.APICalls != 2 || result.Status != KEYWORD_STATUS_TARGET_REACHED {
+		t.Fatalf("%d requests, result %+v", len(backend.requests), result)
+	}
+
+	prompt := backend.requests[1].UserPrompt
+	for _, want := range []string{"need EXACTLY 8", "Missing angle: Value propositions", "Missing angle: Use cases", `"romance audio books" is the same as "romance audiobooks"`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("refinement prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if want := strings.Join(append(good, "romance books"), "|"); strings.Join(result.Keywords, "|") != want {
+		t.Errorf("keywords = %q, want the refined set with the theme last", result.Keywords)
+	}
+}
+
+func TestKeywordAgentRefinesOffThemeSetsAndTrimsToCount(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	offTheme := []string{
+		"audiobooks", "ebooks", "best audiobooks", "popular book recommendations",
+		"unlimited romance audiobooks", "romance audiobooks free trial", "romance audiobooks for commute", "trending romance ebooks",
+	}
+	onTheme := []string{
+		"romance audiobooks", "romance ebooks", "best romance audiobooks", "popular romance recommendations",
+		"unlimited romance audiobooks", "romance audiobooks free trial", "romance audiobooks for commute", "trending romance ebooks",
+	}
+	backend := &scriptedBackend{steps: []scriptedStep{{terms: offTheme}, {terms: onTheme}}}
+
+	result, err := generateKeywords(context.Background(), backend, "romance books", cfg.Keywords, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.requests) != 2 || !strings.Contains(backend.requests[1].UserPrompt, `Off theme: only 50% of the keywords mention "romance books"`) {
+		t.Errorf("%d requests, want a refinement for the off-theme set", len(backend.requests))
+	}
+	if result.Quality.ThemeRelevance != 1 || result.Status != KEYWORD_STATUS_TARGET_REACHED {
+		t.Errorf("result = %+v", result)
+	}
+
+	// More keywords than asked for: the extras go, the theme stays last
+	trimmed := withThemeLast(append(onTheme, "romance novels", "romance books"), "Romance Books", cfg.Keywords)
+	if len(trimmed) != cfg.Keywords.Count+1 || trimmed[len(trimmed)-1] != "romance books" || trimmed[0] != "romance audiobooks" {
+		t.Errorf("trimmed = %q, want %d keywords and the theme", trimmed, cfg.Keywords.Count)
+	}
+}
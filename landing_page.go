@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏗️ LANDING PAGE BUILDER - Theme + Keywords + Search Terms → Static HTML
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Third pipeline stage. Pure local templating (NO API calls!):
+// - Must-target search terms become H2 headings and body copy; the ones
+//   beyond LANDING_PAGE_H2_COUNT are woven into the section bodies
+// - Question-pattern terms become the FAQ section
+// - Keywords go into the meta tags and hero copy
+// Every search term lands on the page at least once. Copy, the lang attribute
+// and question detection follow the run's locale (PageCopy; English is
+// DEFAULT_PAGE_COPY, the other locales live in locales.go).
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	LANDING_PAGE_FILENAME = "index.html"
+	LANDING_PAGE_H2_COUNT = 6 // Content blocks between hero and FAQ
+	LANDING_PAGE_CTA_URL  = "https://www.nextory.com/register"
+	META_DESCRIPTION_MAX  = 160 // Google truncates around here
+)
+
+// PageCopy - One locale's landing page wording; the %s verbs are noted per field
+type PageCopy struct {
+	Title           string // %s theme
+	H1              string // %s theme
+	MetaDescription string // %s theme, %s hero heading
+	Hero            string // %s theme, then %s %s %s the first three keywords
+	SectionBody     string // %s heading term, %s theme
+	SectionRelated  string // %s the other terms the block covers
+	And             string // Last separator in a list: "a, b and c"
+	FAQAnswer       string // %s subject, %s theme
+	FAQHeading      string
+	MoreHeading     string      // %s theme: the H2 collecting the clusters beyond LANDING_PAGE_H2_COUNT
+	CTAs            [2]CTABlock // Heading has %s theme; ButtonLabel and URL are filled in
+	CTAButton       string
+	Footer          string // %s theme
+
+	QuestionWords []string // First words that make a term a question ("where", "how")
+	QuestionLeads []string // Openers whose remainder is the thing being looked for (longest first)
+	SubjectNouns  []string // Words a question's subject may end on; anything else ("online", "cheaply") means it isn't a plain noun phrase
+}
+
+// DEFAULT_PAGE_COPY - English
+var DEFAULT_PAGE_COPY = PageCopy{
+	Title:           "%s – Audiobooks & E-books | Nextory",
+	H1:              "%s: Listen and Read Without Limits",
+	MetaDescription: "Discover %s on Nextory. %s. Stream unlimited audiobooks and e-books – try it free today.",
+	Hero:            "Looking for %s? Nextory gives you %s in one app – from %s to %s. Start listening in seconds, on any device.",
+	SectionBody:     "Searching for %s? Our %s library is hand-picked by editors and updated every week, so you always have something new to enjoy.",
+	SectionRelated:  " Readers who love this also search for %s – and you'll find it all in the same subscription.",
+	And:             "and",
+	FAQAnswer:       "Nextory has %s alongside thousands of other titles, all in one subscription. Listen to %s as audiobooks or read them as e-books – and try it free before you decide.",
+	FAQHeading:      "Frequently asked questions",
+	MoreHeading:     "More %s",
+	CTAs: [2]CTABlock{
+		{Heading: "Start your %s journey today", Text: "Unlimited listening and reading. Cancel anytime."},
+		{Heading: "Your next favourite %s is waiting", Text: "Join thousands of readers and listeners on Nextory."},
+	},
+	CTAButton: "Start your free trial",
+	Footer:    "%s on Nextory – audiobooks, e-books and magazines in one app.",
+
+	QuestionWords: []string{"where", "how", "what", "which", "why", "can", "is", "are"},
+	QuestionLeads: []string{
+		"where can i listen to", "where can i find", "where can i read",
+		"where to listen to", "where to find", "where to read", "where to stream", "where to get",
+		"how to listen to", "how to find", "how to read", "how to get",
+		"what are the best", "what are good", "what are",
+		"is there", "are there",
+	},
+	SubjectNouns: []string{
+		"audiobooks", "audiobook", "ebooks", "ebook", "e-books", "books", "novels", "magazines",
+		"series", "stories", "titles", "classics", "bestsellers",
+	},
+}
+
+// LandingPage - Everything the HTML template needs; also the source of the content brief
+type LandingPage struct {
+	Lang            string // <html lang>, the locale code
+	Theme           string
+	Title           CheckedText // Length-checked like in the brief (brief.go)
+	MetaDescription CheckedText
+	MetaKeywords    string
+	H1              string
+	HeroCopy        string
+	HeroTerms       []string // Search terms the hero covers, its primary first
+	Sections        []LandingSection
+	FAQ             []FAQItem
+	CTAs            []CTABlock
+	FAQHeading      string
+	Footer          string
+}
+
+// LandingSection - One H2 block built around a must-target search term (or the overflow clusters)
+type LandingSection struct {
+	Heading string
+	Topic   string // Cluster label
+	Body    string
+	Terms   []string // Search terms the block covers, heading term first
+}
+
+// FAQItem - Question-pattern search terms answered inline
+type FAQItem struct {
+	Question string
+	Term     string
+	Answer   string
+}
+
+// CTABlock - Conversion block (hero, mid-page, footer)
+type CTABlock struct {
+	Heading     string
+	Text        string
+	ButtonLabel string
+	URL         string
+}
+
+// buildLandingPage - Lays the page out along the result's sections (clusters.go), worded for its locale.
+// Without sections (no cluster stage) every search term is its own cluster.
+func buildLandingPage(result PipelineResult) LandingPage {
+	theme := result.Theme
+	locale := localeFor(result.Locale)
+	wording := locale.Page
+
+	sections := result.Sections
+	if len(sections) == 0 {
+		sections = termSections(theme, result.SearchTerms, wording)
+	}
+
+	page := LandingPage{
+		Lang:         locale.Code,
+		Theme:        theme,
+		MetaKeywords: strings.Join(result.Keywords, ", "),
+		FAQHeading:   wording.FAQHeading,
+		Footer:       fmt.Sprintf(wording.Footer, theme),
+	}
+
+	// Questions go to the FAQ wherever they were clustered; the statements of a
+	// question-majority cluster join the last content block
+	var orphans []string
+	for _, section := range sections {
+		var statements []string
+		for _, term := range section.Terms {
+			if isQuestionTerm(term, wording) {
+				page.FAQ = append(page.FAQ, FAQItem{
+					Question: ensureQuestionMark(capitalizeFirst(term)),
+					Term:     term,
+					Answer:   faqAnswer(theme, questionSubject(theme, term, wording), wording),
+				})
+			} else {
+				statements = append(statements, term)
+			}
+		}
+		switch section.Kind {
+		case SECTION_HERO:
+			page.H1 = fmt.Sprintf(wording.H1, titleCase(section.Heading))
+			page.HeroTerms = statements
+		case SECTION_H2:
+			page.Sections = append(page.Sections, LandingSection{Heading: section.Heading, Topic: section.Topic, Terms: statements})
+		default:
+			orphans = append(orphans, statements...)
+		}
+	}
+	if n := len(page.Sections); n > 0 {
+		page.Sections[n-1].Terms = append(page.Sections[n-1].Terms, orphans...)
+	} else {
+		page.HeroTerms = append(page.HeroTerms, orphans...)
+	}
+
+	hero := titleCase(theme)
+	if len(sections) > 0 {
+		hero = sections[0].Heading
+	}
+	page.Title = titleTag(hero, theme, wording, stopWordSet(locale.StopWords))
+	page.MetaDescription = fitLength(fmt.Sprintf(wording.MetaDescription,
+		strings.ToLower(theme), capitalizeFirst(hero)), META_DESCRIPTION_MIN, META_DESCRIPTION_MAX)
+
+	page.HeroCopy = fmt.Sprintf(wording.Hero,
+		strings.ToLower(theme),
+		strings.ToLower(firstOr(result.Keywords, theme)),
+		strings.ToLower(nthOr(result.Keywords, 1, theme)),
+		strings.ToLower(nthOr(result.Keywords, 2, theme)))
+	page.HeroCopy += relatedSentence(hero, page.HeroTerms, wording)
+
+	// H2 blocks: the heading term, then every other term of the block
+	for i := range page.Sections {
+		section := &page.Sections[i]
+		section.Body = fmt.Sprintf(wording.SectionBody, strings.ToLower(section.Heading), strings.ToLower(theme)) +
+			relatedSentence(section.Heading, section.Terms, wording)
+	}
+
+	for _, cta := range wording.CTAs {
+		cta.Heading = fmt.Sprintf(cta.Heading, strings.ToLower(theme))
+		cta.ButtonLabel, cta.URL = wording.CTAButton, LANDING_PAGE_CTA_URL
+		page.CTAs = append(page.CTAs, cta)
+	}
+
+	return page
+}
+
+// termSections - The section layout for plain search terms: one cluster per term
+func termSections(theme string, terms []string, wording PageCopy) []PageSection {
+	var clusters []TermCluster
+	var records []SearchTermRecord
+	for i, term := range terms {
+		clusters = append(clusters, TermCluster{ID: i + 1, Primary: term, Terms: []string{term}})
+		if isQuestionTerm(term, wording) {
+			records = append(records, SearchTermRecord{Term: term, Patterns: []string{"questions"}})
+		}
+	}
+	return mapClustersToSections(theme, clusters, records, wording)
+}
+
+// relatedSentence - The block's terms other than its heading, as one sentence ("" when there are none)
+func relatedSentence(heading string, terms []string, wording PageCopy) string {
+	var related []string
+	for _, term := range terms {
+		if !strings.EqualFold(term, heading) {
+			related = append(related, strings.ToLower(term))
+		}
+	}
+	if len(related) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(wording.SectionRelated, joinList(related, wording.And))
+}
+
+// renderLandingPage - Writes the page to outputDir/index.html and returns the path
+func renderLandingPage(page LandingPage, outputDir string) (string, error) {
+	tmpl, err := template.New("landing").Parse(LANDING_PAGE_TEMPLATE)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse landing page template: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(outputDir, LANDING_PAGE_FILENAME)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, page); err != nil {
+		return "", fmt.Errorf("failed to render landing page: %w", err)
+	}
+
+	log.Printf("🏗️  Rendered landing page with %d sections and %d FAQs", len(page.Sections), len(page.FAQ))
+	return path, nil
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🛠️ HELPERS - Copy Utilities
+// ═══════════════════════════════════════════════════════════════════════════
+
+func isQuestionTerm(term string, wording PageCopy) bool {
+	lower := strings.ToLower(strings.TrimSpace(term))
+	for _, word := range wording.QuestionWords {
+		if strings.HasPrefix(lower, word+" ") {
+			return true
+		}
+	}
+	return strings.HasSuffix(lower, "?")
+}
+
+// questionSubject - "where to listen to romance audiobooks?" → "romance audiobooks".
+// Questions we can't take apart safely ("which app works offline", "how to get X cheaply",
+// anything in a locale without QuestionLeads) answer about the theme.
+func questionSubject(theme, question string, wording PageCopy) string {
+	theme = strings.ToLower(strings.TrimSpace(theme))
+	q := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(question), "?")))
+	for _, lead := range wording.QuestionLeads {
+		rest, ok := strings.CutPrefix(q, lead+" ")
+		if !ok {
+			continue
+		}
+		words := strings.Fields(rest)
+		if len(words) == 0 {
+			break
+		}
+		last := words[len(words)-1]
+		if slices.Contains(wording.SubjectNouns, last) || slices.Contains(strings.Fields(theme), last) {
+			return strings.Join(words, " ")
+		}
+		break
+	}
+	return theme
+}
+
+// faqAnswer - subject must be a noun phrase (a statement term or questionSubject's output)
+func faqAnswer(theme, subject string, wording PageCopy) string {
+	return fmt.Sprintf(wording.FAQAnswer, strings.ToLower(subject), strings.ToLower(theme))
+}
+
+// joinList - "a", "a and b", "a, b and c"
+func joinList(items []string, and string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + and + " " + items[len(items)-1]
+}
+
+func ensureQuestionMark(s string) string {
+	if strings.HasSuffix(s, "?") {
+		return s
+	}
+	return s + "?"
+}
+
+func capitalizeFirst(s string) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) == 0 {
+		return ""
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = capitalizeFirst(w)
+	}
+	return strings.Join(words, " ")
+}
+
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max-1])
+	if idx := strings.LastIndex(cut, " "); idx > 0 {
+		cut = cut[:idx]
+	}
+	return cut + "…"
+}
+
+func firstOr(items []string, fallback string) string {
+	return nthOr(items, 0, fallback)
+}
+
+func nthOr(items []string, n int, fallback string) string {
+	if n < len(items) {
+		return items[n]
+	}
+	return fallback
+}
+
+// slugify - "Romance Books!" → "romance-books" (used for output directories)
+func slugify(s string) string {
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📄 HTML TEMPLATE
+// ═══════════════════════════════════════════════════════════════════════════
+
+const LANDING_PAGE_TEMPLATE = `<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title.Text}}</title>
+  <meta name="description" content="{{.MetaDescription.Text}}">
+  <meta name="keywords" content="{{.MetaKeywords}}">
+  <meta property="og:title" content="{{.Title.Text}}">
+  <meta property="og:description" content="{{.MetaDescription.Text}}">
+  <style>
+    body { font-family: system-ui, sans-serif; margin: 0; color: #1a1a1a; line-height: 1.6; }
+    header, section, footer { max-width: 960px; margin: 0 auto; padding: 2rem 1.5rem; }
+    .hero { text-align: center; padding-top: 4rem; }
+    .cta { background: #f6f2ea; border-radius: 12px; text-align: center; margin: 2rem auto; }
+    .cta a { display: inline-block; background: #1a1a1a; color: #fff; padding: 0.8rem 1.6rem; border-radius: 999px; text-decoration: none; }
+    .faq dt { font-weight: 600; margin-top: 1.2rem; }
+  </style>
+</head>
+<body>
+  <header class="hero">
+    <h1>{{.H1}}</h1>
+    <p>{{.HeroCopy}}</p>
+    {{with index .CTAs 0}}<a class="button" href="{{.URL}}">{{.ButtonLabel}}</a>{{end}}
+  </header>
+  <main>
+    {{range .Sections}}
+    <section>
+      <h2>{{.Heading}}</h2>
+      <p>{{.Body}}</p>
+    </section>
+    {{end}}
+    {{range .CTAs}}
+    <section class="cta">
+      <h2>{{.Heading}}</h2>
+      <p>{{.Text}}</p>
+      <a href="{{.URL}}">{{.ButtonLabel}}</a>
+    </section>
+    {{end}}
+    {{if .FAQ}}
+    <section class="faq">
+      <h2>{{.FAQHeading}}</h2>
+      <dl>
+        {{range .FAQ}}
+        <dt>{{.Question}}</dt>
+        <dd>{{.Answer}}</dd>
+        {{end}}
+      </dl>
+    </section>
+    {{end}}
+  </main>
+  <footer>
+    <p>{{.Footer}}</p>
+  </footer>
+</body>
+</html>
+`
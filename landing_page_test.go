@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFAQAnswersUseQuestionSubject(t *testing.T) {
+	cases := map[string]string{
+		"where to listen to romance books audiobooks": "romance books audiobooks",
+		"How to get romance ebooks?":                  "romance ebooks",
+		"what are the best romance series":            "romance series",
+		"which romance app works offline":             "romance books", // Falls back to the theme
+		"how to get romance books cheaply":            "romance books", // Not a noun phrase
+		"where to stream romance books online":        "romance books",
+	}
+	for question, want := range cases {
+		if got := questionSubject("Romance Books", question, DEFAULT_PAGE_COPY); got != want {
+			t.Errorf("questionSubject(%q) = %q, want %q", question, got, want)
+		}
+	}
+
+	page := buildLandingPage(PipelineResult{Theme: "romance books", SearchTerms: []string{"where to listen to romance books audiobooks"}})
+	if len(page.FAQ) != 1 {
+		t.Fatalf("got %d FAQs, want 1", len(page.FAQ))
+	}
+	if answer := page.FAQ[0].Answer; strings.Contains(answer, "where to") {
+		t.Errorf("answer repeats the raw query: %q", answer)
+	}
+}
+
+func TestSectionsNeverCrossReferenceThemselves(t *testing.T) {
+	for n := 1; n <= 8; n++ {
+		var terms []string
+		for i := 0; i < n; i++ {
+			terms = append(terms, fmt.Sprintf("romance term %d", i))
+		}
+		page := buildLandingPage(PipelineResult{Theme: "romance books", SearchTerms: terms})
+		for i, section := range page.Sections {
+			if strings.Contains(section.Body, "also search for "+strings.ToLower(section.Heading)) {
+				t.Errorf("n=%d: section %d cross-references its own heading", n, i)
+			}
+		}
+		if n == 1 && (len(page.Sections) != 0 || strings.Contains(page.HeroCopy, "also search for")) {
+			t.Errorf("n=1: the single term is the hero, without a related-search sentence")
+		}
+	}
+}
+
+func TestLandingPagePlacesEveryTerm(t *testing.T) {
+	offline := generateFallbackSearchTerms("romance books", nil, 15, LOCALES[DEFAULT_LOCALE])
+	long := append([]string(nil), offline...)
+	for i := 0; i < 10; i++ {
+		long = append(long, fmt.Sprintf("romance pick %d", i), fmt.Sprintf("where to find romance title %d", i))
+	}
+
+	for _, terms := range [][]string{offline, long} {
+		page := buildLandingPage(PipelineResult{Theme: "romance books", SearchTerms: terms})
+		var text strings.Builder
+		fmt.Fprintln(&text, strings.ToLower(page.H1), page.HeroCopy)
+		for _, section := range page.Sections {
+			fmt.Fprintln(&text, strings.ToLower(section.Heading), section.Body)
+		}
+		for _, faq := range page.FAQ {
+			fmt.Fprintln(&text, strings.ToLower(faq.Question), faq.Answer)
+			if strings.HasPrefix(faq.Question, "Does Nextory have") {
+				t.Errorf("synthesized FAQ question %q", faq.Question)
+			}
+		}
+		for _, term := range terms {
+			if !strings.Contains(text.String(), strings.ToLower(term)) {
+				t.Errorf("%d terms: %q is not on the page", len(terms), term)
+			}
+		}
+	}
+}
+
+func TestOfflineSwedishRunRendersSwedishPage(t *testing.T) {
+	for _, env := range []string{"OPENROUTER_API_KEY", "LOCAL_LLM_BASE_URL", CONFIG_ENV_PATH} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	if code := runCLI([]string{"run", "--idea", "romance books", "--locale", "sv", "--output", dir}); code != 0 {
+		t.Fatalf("run exited with %d", code)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "romance-books", LANDING_PAGE_FILENAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	for _, want := range []string{`<html lang="sv">`, "<h2>Vanliga frågor</h2>", "<dt>Var kan man streama romance böcker online?</dt>", "Prova gratis"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page is missing %q", want)
+		}
+	}
+	for _, english := range []string{"Frequently asked questions", "Start your free trial", "Searching for", "audiobooks"} {
+		if strings.Contains(html, english) {
+			t.Errorf("Swedish page contains English %q", english)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🌍 LOCALES - Native-Language Prompts and Pattern Dictionaries
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// locale = "sv" (or --locale sv) switches both stages to Swedish:
+//   - prompts ask for terms people in that market actually type (not translations)
+//   - pattern rules detect "bästa", "gratis", "hur ..." instead of "best", "free", "how ..."
+//
+// Rule ids are the same in every locale, so [[search_terms.patterns]]
+// overrides and scores stay comparable across markets.
+//
+// Prompts you customised in the config are kept; only the built-in
+// defaults are swapped for the locale's.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const DEFAULT_LOCALE = "en"
+
+// Locale - Everything language-specific about a run
+type Locale struct {
+	Code      string
+	Language  string   // As written in prompts: "Swedish"
+	Market    string   // "Sweden"
+	Countries []string // Market codes served (ISO 3166): "SE"
+	StopWords []string // Words that never make two queries different (dedupe.go)
+
+	Patterns []PatternRule // Same ids as DEFAULT_PATTERN_RULES
+
+	// Keyword prompt angles, one "- Label: a, b, c" line each, in KEYWORD_ANGLE_IDS order;
+	// also the vocabulary the KeywordAgent checks coverage with
+	KeywordAngles []string
+	UseCases      string // Extra ✓ line in the initial search term prompt
+
+	Fallback FallbackTemplates // Offline keywords and search terms (fallback.go)
+	Page     PageCopy          // Landing page wording and question detection (landing_page.go)
+}
+
+// LOCALES - Built-in locales by code
+var LOCALES = map[string]Locale{
+	"en": {
+		Code: "en", Language: "English", Market: "English-speaking markets",
+		Countries:     []string{"GB", "US", "IE"},
+		StopWords:     DEFAULT_STOP_WORDS,
+		Patterns:      DEFAULT_PATTERN_RULES,
+		KeywordAngles: DEFAULT_KEYWORD_ANGLES,
+		Fallback:      DEFAULT_FALLBACK_TEMPLATES,
+		Page:          DEFAULT_PAGE_COPY,
+	},
+	"sv": {
+		Code: "sv", Language: "Swedish", Market: "Sweden",
+		Countries: []string{"SE"},
+		StopWords: []string{"en", "ett", "för", "till", "med", "och", "på", "av"},
+		Patterns: []PatternRule{
+			{ID: "comparisons", Description: "Comparison terms", Example: "'X vs Y', 'alternativ till X'",
+				Keywords: []string{" vs ", " mot ", "alternativ", "jämför"}},
+			{ID: "questions", Description: "Question-based", Example: "'var hittar man X', 'hur får man X'",
+				Regex: `^(var|hur|vad|vilken|vilka|vilket|varför) `},
+			{ID: "best_lists", Description: "Best/Top lists", Example: "'bästa X för Y', 'topp 10 X 2025'",
+				Keywords: []string{"bästa ", "topp ", "populäraste", "mest populära"}},
+			{ID: "value_terms", Description: "Value-focused", Example: "'obegränsat X', 'X gratis provperiod'",
+				Keywords: []string{"obegränsa", "gratis", "provperiod", "prova ", "billig"}},
+			{ID: "format_mix", Description: "Format combinations", Example: "'X ljudböcker', 'X e-böcker'",
+				Keywords: []string{"ljudbok", "ljudböcker", "e-bok", "e-böcker", "bok", "böcker", "tidning"},
+				Weight:   float64Ptr(0.5)},
+			{ID: "user_intent", Description: "User intent", Example: "'X för nybörjare', 'X för pendling'",
+				Keywords: []string{" för ", " till "}},
+		},
+		KeywordAngles: []string{
+			"- Format variations: ljudböcker, e-böcker, tidningar",
+			"- Intent signals: bästa, topp, populära, nya, tips",
+			"- Value propositions: obegränsat, familj, streaming, gratis provperiod",
+			"- Use cases: för pendling, för familjen, för barn",
+		},
+		UseCases: "'X för familjen', 'X för barn'",
+		Fallback: FallbackTemplates{
+			Books:       "böcker",
+			Formats:     []string{"ljudböcker", "e-böcker", "tidningar"},
+			FormatWords: []string{"ljudböcker", "ljudbok", "e-böcker", "e-bok", "böcker", "bok", "romaner", "roman", "tidningar", "tidning"},
+			Intents:     []string{"bästa", "topp", "populära", "nya"},
+			ValueProps:  []string{"obegränsat", "gratis provperiod", "streaming", "familj"},
+			UseCases:    []string{"för pendling", "för familjen", "för barn", "för nybörjare"},
+			Keywords: []string{
+				"{audiobooks}", "{ebooks}", "bästa {audiobooks}", "populära {books} tips",
+				"obegränsat med {audiobooks}", "{audiobooks} gratis provperiod", "{audiobooks} för pendling", "nya {ebooks}",
+			},
+			SearchTerms: []string{
+				"bästa nya {audiobooks}", "var kan man streama {books} online", "{books} streaming vs köpa",
+				"{ebooks} gratis provperiod", "{books} för pendling", "{magazines} prenumeration",
+				"topp 10 {books} serier", "hur får man {books} billigt", "jämför appar för {books}",
+				"obegränsat med {books} till fast pris", "vilken app har {books} offline", "{audiobooks} med kända uppläsare",
+				"dela {books} med familjen", "{books} godnattsagor för barn", "mest populära {books} denna månad",
+			},
+		},
+		Page: PageCopy{
+			Title:           "%s – Ljudböcker & e-böcker | Nextory",
+			H1:              "%s: Lyssna och läs utan gränser",
+			MetaDescription: "Upptäck %s på Nextory. %s. Streama obegränsat med ljudböcker och e-böcker – prova gratis idag.",
+			Hero:            "Letar du efter %s? Nextory ger dig %s i en app – från %s till %s. Börja lyssna på några sekunder, på alla enheter.",
+			SectionBody:     "Söker du %s? Vårt bibliotek med %s väljs ut av redaktörer och uppdateras varje vecka, så du alltid har något nytt att upptäcka.",
+			SectionRelated:  " Den som gillar det här söker också efter %s – och allt finns i samma prenumeration.",
+			And:             "och",
+			FAQAnswer:       "Nextory har %s bland tusentals andra titlar, allt i en prenumeration. Lyssna på %s som ljudböcker eller läs dem som e-böcker – och prova gratis innan du bestämmer dig.",
+			FAQHeading:      "Vanliga frågor",
+			MoreHeading:     "Mer %s",
+			CTAs: [2]CTABlock{
+				{Heading: "Kom igång med %s idag", Text: "Obegränsat lyssnande och läsande. Avsluta när du vill."},
+				{Heading: "Din nästa favorit inom %s väntar", Text: "Gör som tusentals läsare och lyssnare på Nextory."},
+			},
+			CTAButton:     "Prova gratis",
+			Footer:        "%s på Nextory – ljudböcker, e-böcker och tidningar i en app.",
+			QuestionWords: []string{"var", "hur", "vad", "vilken", "vilka", "vilket", "varför", "kan", "finns"},
+		},
+	},
+	"fi": {
+		Code: "fi", Language: "Finnish", Market: "Finland",
+		Countries: []string{"FI"},
+		StopWords: []string{"ja"},
+		Patterns: []PatternRule{
+			{ID: "comparisons", Description: "Comparison terms", Example: "'X vs Y', 'X vaihtoehto'",
+				Keywords: []string{" vs ", " vai ", "vaihtoeh", "vertailu"}},
+			{ID: "questions", Description: "Question-based", Example: "'mistä löytää X', 'miten saada X'",
+				Regex: `^(mistä|miten|mikä|mitkä|missä|kuinka|miksi) `},
+			{ID: "best_lists", Description: "Best/Top lists", Example: "'parhaat X', 'top 10 X 2025'",
+				Keywords: []string{"paras ", "parhaat ", "top ", "suosituin", "suosituimmat"}},
+			{ID: "value_terms", Description: "Value-focused", Example: "'rajattomasti X', 'X ilmaiseksi kokeiluun'",
+				Keywords: []string{"rajaton", "rajattom", "ilmai", "kokeilu", "edullinen"}},
+			{ID: "format_mix", Description: "Format combinations", Example: "'X äänikirjat', 'X e-kirjat'",
+				Keywords: []string{"äänikirj", "e-kirj", "kirja", "kirjat", "lehti", "lehdet"},
+				Weight:   float64Ptr(0.5)},
+			// Finnish marks "for" with the allative case: lapsille, aloittelijoille, työmatkalle
+			{ID: "user_intent", Description: "User intent", Example: "'X aloittelijoille', 'X työmatkalle'",
+				Regex: `\S+lle(\s|$)`},
+		},
+		KeywordAngles: []string{
+			"- Format variations: äänikirjat, e-kirjat, lehdet",
+			"- Intent signals: parhaat, suosituimmat, uusimmat, suositukset",
+			"- Value propositions: rajattomasti, perheelle, suoratoisto, ilmainen kokeilu",
+			"- Use cases: työmatkalle, perheelle, lapsille",
+		},
+		UseCases: "'X perheelle', 'X lapsille'",
+		Fallback: FallbackTemplates{
+			Books:       "kirjat",
+			Formats:     []string{"äänikirjat", "e-kirjat", "lehdet"},
+			FormatWords: []string{"äänikirjat", "äänikirja", "e-kirjat", "e-kirja", "kirjat", "kirja", "romaanit", "romaani", "lehdet", "lehti"},
+			Intents:     []string{"parhaat", "suosituimmat", "uusimmat", "top"},
+			ValueProps:  []string{"rajattomasti", "ilmainen kokeilu", "suoratoisto", "perheen"},
+			UseCases:    []string{"työmatkalle", "perheelle", "lapsille", "aloittelijoille"},
+			Keywords: []string{
+				"{audiobooks}", "{ebooks}", "parhaat {audiobooks}", "{books} suositukset",
+				"rajattomasti {audiobooks}", "{audiobooks} ilmainen kokeilu", "{audiobooks} työmatkalle", "uusimmat {ebooks}",
+			},
+			SearchTerms: []string{
+				"parhaat uudet {audiobooks}", "mistä voi kuunnella {audiobooks} netissä", "{books} suoratoisto vai ostaminen",
+				"{ebooks} ilmaiseksi kokeiluun", "{books} työmatkalle", "{magazines} tilaus",
+				"top 10 {books} sarjat", "miten saada {books} edullisesti", "{books} sovellusten vertailu",
+				"rajattomasti {books} kiinteään hintaan", "missä sovelluksessa {books} toimii offline", "{audiobooks} tunnettujen lukijoiden lukemina",
+				"{books} koko perheelle", "{books} iltasaduiksi lapsille", "suosituimmat {books} tässä kuussa",
+			},
+		},
+		Page: PageCopy{
+			Title:           "%s – Äänikirjat & e-kirjat | Nextory",
+			H1:              "%s: Kuuntele ja lue rajattomasti",
+			MetaDescription: "Löydä %s Nextorysta. %s. Rajattomasti äänikirjoja ja e-kirjoja – kokeile ilmaiseksi jo tänään.",
+			Hero:            "Etsitkö %s? Nextory tuo sinulle %s yhteen sovellukseen – %s ja %s mukaan lukien. Aloita kuuntelu sekunneissa, millä tahansa laitteella.",
+			SectionBody:     "Haetko %s? Toimittajamme valitsevat %s käsin ja päivittävät valikoimaa joka viikko, joten sinulla on aina jotain uutta.",
+			SectionRelated:  " Tästä pitävät hakevat myös %s – ja kaikki löytyy samasta tilauksesta.",
+			And:             "ja",
+			FAQAnswer:       "Nextorysta löytyy %s tuhansien muiden nimikkeiden joukosta, kaikki yhdellä tilauksella. Kuuntele %s äänikirjoina tai lue e-kirjoina – ja kokeile ilmaiseksi ennen kuin päätät.",
+			FAQHeading:      "Usein kysytyt kysymykset",
+			MoreHeading:     "Lisää: %s",
+			CTAs: [2]CTABlock{
+				{Heading: "Aloita %s jo tänään", Text: "Rajattomasti kuunneltavaa ja luettavaa. Peru milloin tahansa."},
+				{Heading: "Seuraava suosikkisi odottaa: %s", Text: "Liity tuhansien lukijoiden ja kuuntelijoiden joukkoon Nextoryssa."},
+			},
+			CTAButton:     "Kokeile ilmaiseksi",
+			Footer:        "%s Nextoryssa – äänikirjat, e-kirjat ja lehdet yhdessä sovelluksessa.",
+			QuestionWords: []string{"mistä", "miten", "mikä", "mitkä", "missä", "kuinka", "miksi", "voiko", "onko"},
+		},
+	},
+	"de": {
+		Code: "de", Language: "German", Market: "Germany",
+		Countries: []string{"DE", "AT"},
+		StopWords: []string{"der", "die", "das", "für", "mit", "und", "zum", "zur"},
+		Patterns: []PatternRule{
+			{ID: "comparisons", Description: "Comparison terms", Example: "'X vs Y', 'X Alternative'",
+				Keywords: []string{" vs ", " versus ", "alternative", "vergleich"}},
+			{ID: "questions", Description: "Question-based", Example: "'wo finde ich X', 'wie bekomme ich X'",
+				Regex: `^(wo|wie|was|welche|welcher|welches|warum) `},
+			{ID: "best_lists", Description: "Best/Top lists", Example: "'beste X für Y', 'top 10 X 2025'",
+				Keywords: []string{"beste ", "besten ", "top ", "beliebteste"}},
+			{ID: "value_terms", Description: "Value-focused", Example: "'X unbegrenzt', 'X kostenlos testen'",
+				Keywords: []string{"unbegrenzt", "kostenlos", "gratis", "probeabo", "testen", "günstig"}},
+			{ID: "format_mix", Description: "Format combinations", Example: "'X Hörbücher', 'X E-Books'",
+				Keywords: []string{"hörbuch", "hörbücher", "e-book", "ebook", "buch", "bücher", "magazin", "zeitschrift"},
+				Weight:   float64Ptr(0.5)},
+			{ID: "user_intent", Description: "User intent", Example: "'X für Anfänger', 'X zum Einschlafen'",
+				Keywords: []string{" für ", " zum ", " beim "}},
+		},
+		KeywordAngles: []string{
+			"- Format variations: Hörbücher, E-Books, Zeitschriften",
+			"- Intent signals: beste, top, beliebt, neu, Empfehlungen",
+			"- Value propositions: unbegrenzt, Familie, Streaming, kostenlos testen",
+			"- Use cases: für den Arbeitsweg, für die Familie, für Kinder",
+		},
+		UseCases: "'X für die Familie', 'X für Kinder'",
+		Fallback: FallbackTemplates{
+			Books:       "bücher",
+			Formats:     []string{"hörbücher", "e-books", "zeitschriften"},
+			FormatWords: []string{"hörbücher", "hörbuch", "e-books", "e-book", "bücher", "buch", "romane", "roman", "zeitschriften", "zeitschrift"},
+			Intents:     []string{"beste", "top", "beliebte", "neue"},
+			ValueProps:  []string{"unbegrenzt", "kostenlos testen", "streaming", "familie"},
+			UseCases:    []string{"für den arbeitsweg", "für die familie", "für kinder", "für anfänger"},
+			Keywords: []string{
+				"{audiobooks}", "{ebooks}", "beste {audiobooks}", "{books} empfehlungen",
+				"{audiobooks} unbegrenzt", "{audiobooks} kostenlos testen", "{audiobooks} für den arbeitsweg", "neue {ebooks}",
+			},
+			SearchTerms: []string{
+				"beste neue {audiobooks}", "wo kann man {books} online streamen", "{books} streamen vs kaufen",
+				"{ebooks} kostenlos testen", "{books} für den arbeitsweg", "{magazines} im abo",
+				"top 10 {books} reihen", "wie bekomme ich {books} günstig", "{books} apps im vergleich",
+				"{books} unbegrenzt zum festpreis", "welche app hat {books} offline", "{audiobooks} gelesen von bekannten sprechern",
+				"{books} mit der familie teilen", "{books} zum einschlafen für kinder", "beliebteste {books} diesen monat",
+			},
+		},
+		Page: PageCopy{
+			Title:           "%s – Hörbücher & E-Books | Nextory",
+			H1:              "%s: Hören und lesen ohne Limit",
+			MetaDescription: "Entdecke %s bei Nextory. %s. Unbegrenzt Hörbücher und E-Books streamen – jetzt kostenlos testen.",
+			Hero:            "Auf der Suche nach %s? Nextory bietet dir %s in einer App – von %s bis %s. In Sekunden loshören, auf jedem Gerät.",
+			SectionBody:     "Du suchst %s? Unsere Auswahl an %s stellt die Redaktion zusammen und aktualisiert sie jede Woche, damit du immer etwas Neues entdeckst.",
+			SectionRelated:  " Wer das mag, sucht auch nach %s – und findet alles im selben Abo.",
+			And:             "und",
+			FAQAnswer:       "Nextory hat %s neben tausenden weiteren Titeln, alles in einem Abo. Höre %s als Hörbücher oder lies sie als E-Books – und teste kostenlos, bevor du dich entscheidest.",
+			FAQHeading:      "Häufige Fragen",
+			MoreHeading:     "Mehr %s",
+			CTAs: [2]CTABlock{
+				{Heading: "Starte heute mit %s", Text: "Unbegrenzt hören und lesen. Jederzeit kündbar."},
+				{Heading: "Dein nächster Favorit wartet: %s", Text: "Schließ dich tausenden Lesern und Hörern bei Nextory an."},
+			},
+			CTAButton:     "Jetzt kostenlos testen",
+			Footer:        "%s bei Nextory – Hörbücher, E-Books und Magazine in einer App.",
+			QuestionWords: []string{"wo", "wie", "was", "welche", "welcher", "welches", "warum", "kann", "gibt", "ist"},
+		},
+	},
+	"nl": {
+		Code: "nl", Language: "Dutch", Market: "the Netherlands",
+		Countries: []string{"NL"},
+		StopWords: []string{"de", "het", "een", "en", "voor", "van", "met"},
+		Patterns: []PatternRule{
+			{ID: "comparisons", Description: "Comparison terms", Example: "'X vs Y', 'alternatief voor X'",
+				Keywords: []string{" vs ", " versus ", "alternatief", "vergelijk"}},
+			{ID: "questions", Description: "Question-based", Example: "'waar vind ik X', 'hoe krijg ik X'",
+				Regex: `^(waar|hoe|wat|welke|welk|waarom) `},
+			{ID: "best_lists", Description: "Best/Top lists", Example: "'beste X voor Y', 'top 10 X 2025'",
+				Keywords: []string{"beste ", "top ", "populairste", "meest populaire"}},
+			{ID: "value_terms", Description: "Value-focused", Example: "'onbeperkt X', 'X gratis proberen'",
+				Keywords: []string{"onbeperkt", "gratis", "proefperiode", "proberen", "goedkoop", "voordelig"}},
+			{ID: "format_mix", Description: "Format combinations", Example: "'X luisterboeken', 'X e-books'",
+				Keywords: []string{"luisterboek", "e-book", "ebook", "boek", "tijdschrift"},
+				Weight:   float64Ptr(0.5)},
+			{ID: "user_intent", Description: "User intent", Example: "'X voor beginners', 'X voor onderweg'",
+				Keywords: []string{" voor ", " tijdens "}},
+		},
+		KeywordAngles: []string{
+			"- Format variations: luisterboeken, e-books, tijdschriften",
+			"- Intent signals: beste, top, populair, nieuw, aanraders",
+			"- Value propositions: onbeperkt, gezin, streaming, gratis proefperiode",
+			"- Use cases: voor onderweg, voor het gezin, voor kinderen",
+		},
+		UseCases: "'X voor het gezin', 'X voor kinderen'",
+		Fallback: FallbackTemplates{
+			Books:       "boeken",
+			Formats:     []string{"luisterboeken", "e-books", "tijdschriften"},
+			FormatWords: []string{"luisterboeken", "luisterboek", "e-books", "e-book", "boeken", "boek", "romans", "roman", "tijdschriften", "tijdschrift"},
+			Intents:     []string{"beste", "top", "populaire", "nieuwe"},
+			ValueProps:  []string{"onbeperkt", "gratis proefperiode", "streaming", "gezin"},
+			UseCases:    []string{"voor onderweg", "voor het gezin", "voor kinderen", "voor beginners"},
+			Keywords: []string{
+				"{audiobooks}", "{ebooks}", "beste {audiobooks}", "{books} aanraders",
+				"onbeperkt {audiobooks}", "{audiobooks} gratis proefperiode", "{audiobooks} voor onderweg", "nieuwe {ebooks}",
+			},
+			SearchTerms: []string{
+				"beste nieuwe {audiobooks}", "waar kan ik {books} online streamen", "{books} streamen vs kopen",
+				"{ebooks} gratis proberen", "{books} voor onderweg", "{magazines} abonnement",
+				"top 10 {books} series", "hoe krijg ik {books} goedkoop", "{books} apps vergelijken",
+				"onbeperkt {books} voor een vast bedrag", "welke app heeft {books} offline", "{audiobooks} voorgelezen door bekende stemmen",
+				"{books} delen met het gezin", "{books} voor het slapengaan", "populairste {books} deze maand",
+			},
+		},
+		Page: PageCopy{
+			Title:           "%s – Luisterboeken & e-books | Nextory",
+			H1:              "%s: Luister en lees zonder grenzen",
+			MetaDescription: "Ontdek %s bij Nextory. %s. Onbeperkt luisterboeken en e-books streamen – probeer het vandaag gratis.",
+			Hero:            "Op zoek naar %s? Nextory geeft je %s in één app – van %s tot %s. Binnen enkele seconden luisteren, op elk apparaat.",
+			SectionBody:     "Zoek je %s? Onze collectie %s wordt door redacteuren samengesteld en elke week bijgewerkt, zodat je altijd iets nieuws ontdekt.",
+			SectionRelated:  " Wie dit leuk vindt, zoekt ook naar %s – en vindt het allemaal in hetzelfde abonnement.",
+			And:             "en",
+			FAQAnswer:       "Nextory heeft %s naast duizenden andere titels, allemaal in één abonnement. Luister naar %s als luisterboek of lees ze als e-book – en probeer het gratis voordat je beslist.",
+			FAQHeading:      "Veelgestelde vragen",
+			MoreHeading:     "Meer %s",
+			CTAs: [2]CTABlock{
+				{Heading: "Begin vandaag met %s", Text: "Onbeperkt luisteren en lezen. Altijd opzegbaar."},
+				{Heading: "Je volgende favoriet wacht: %s", Text: "Sluit je aan bij duizenden lezers en luisteraars op Nextory."},
+			},
+			CTAButton:     "Probeer gratis",
+			Footer:        "%s bij Nextory – luisterboeken, e-books en tijdschriften in één app.",
+			QuestionWords: []string{"waar", "hoe", "wat", "welke", "welk", "waarom", "kan", "is", "zijn"},
+		},
+	},
+}
+
+// lookupLocale - A built-in locale, or an error listing the known codes
+func lookupLocale(code string) (Locale, error) {
+	locale, ok := LOCALES[strings.ToLower(strings.TrimSpace(code))]
+	if !ok {
+		return Locale{}, fmt.Errorf("unknown locale %q (known: %s)", code, strings.Join(localeCodes(), ", "))
+	}
+	return locale, nil
+}
+
+// localeFor - The built-in locale, English for unknown codes (results and configs that were never resolved)
+func localeFor(code string) Locale {
+	if locale, err := lookupLocale(code); err == nil {
+		return locale
+	}
+	return LOCALES[DEFAULT_LOCALE]
+}
+
+func localeCodes() []string {
+	codes := make([]string, 0, len(LOCALES))
+	for code := range LOCALES {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📝 LOCALIZED PROMPTS - English instructions, native output and examples
+// ═══════════════════════════════════════════════════════════════════════════
+
+// LocalePrompts - Built-in prompt defaults for one locale
+type LocalePrompts struct {
+	KeywordSystem             string
+	KeywordUserTemplate       string
+	KeywordRefinementTemplate string
+	SearchTerms               SearchTermPrompts
+}
+
+// Prompts - English is the original constants; every other locale is built from its data
+func (l Locale) Prompts() LocalePrompts {
+	if l.Code == DEFAULT_LOCALE {
+		return LocalePrompts{
+			KeywordSystem:             KEYWORD_SYSTEM_PROMPT,
+			KeywordUserTemplate:       KEYWORD_USER_PROMPT_TEMPLATE,
+			KeywordRefinementTemplate: KEYWORD_REFINEMENT_USER_PROMPT_TEMPLATE,
+			SearchTerms: SearchTermPrompts{
+				InitialSystem:          INITIAL_GENERATION_SYSTEM_PROMPT,
+				InitialUserTemplate:    INITIAL_GENERATION_USER_PROMPT_TEMPLATE,
+				RefinementSystem:       REFINEMENT_SYSTEM_PROMPT,
+				RefinementUserTemplate: REFINEMENT_USER_PROMPT_TEMPLATE,
+				TopUpUserTemplate:      TOP_UP_USER_PROMPT_TEMPLATE,
+			},
+		}
+	}
+
+	native := fmt.Sprintf(`
+LANGUAGE: Write every term in %s, exactly as people in %s type it into Google.
+Do NOT translate English search terms - use the phrasing, word order and compounds native searchers use.`,
+		l.Language, l.Market)
+
+	var requirements []string
+	for _, rule := range l.Patterns {
+		requirements = append(requirements, fmt.Sprintf("✓ %s (e.g., %s)", rule.Description, rule.Example))
+	}
+	requirements = append(requirements, fmt.Sprintf("✓ Specific use cases (e.g., %s)", l.UseCases))
+
+	return LocalePrompts{
+		KeywordSystem: fmt.Sprintf("You are a SEO expert specializing in book discovery and audiobook streaming services in %s.", l.Market),
+		KeywordUserTemplate: `Generate %d SEO keywords in ` + l.Language + ` for a Nextory landing page about "%s".
+
+Consider various angles based on theme, for example:
+` + escapePercent(strings.Join(l.KeywordAngles, "\n")) + `
+
+Mix broad discovery terms with long-tail conversion keywords.` + escapePercent(native) + `
+Use the submit_keywords tool.`,
+		KeywordRefinementTemplate: KEYWORD_REFINEMENT_USER_PROMPT_TEMPLATE + escapePercent(native),
+		SearchTerms: SearchTermPrompts{
+			InitialSystem: INITIAL_GENERATION_SYSTEM_PROMPT + fmt.Sprintf("\nYou write natively in %s for searchers in %s.", l.Language, l.Market),
+			InitialUserTemplate: `
+Generate EXACTLY %d specific, must-target search terms for a Nextory landing page.
+
+Theme: "%s"
+Base Keywords: %s
+
+REQUIREMENTS - You MUST include diverse search patterns:
+` + escapePercent(strings.Join(requirements, "\n")) + `
+` + escapePercent(native) + `
+
+Make them SPECIFIC and CONVERSION-FOCUSED!
+Use the submit_search_terms tool with EXACTLY %d terms.`,
+			RefinementSystem:       REFINEMENT_SYSTEM_PROMPT + fmt.Sprintf(" All terms are in %s, written the way searchers in %s type them.", l.Language, l.Market),
+			RefinementUserTemplate: REFINEMENT_USER_PROMPT_TEMPLATE + escapePercent(native),
+			TopUpUserTemplate:      TOP_UP_USER_PROMPT_TEMPLATE + escapePercent(native),
+		},
+	}
+}
+
+// escapePercent - Literal text spliced into a Sprintf template
+func escapePercent(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// isBuiltinPrompt - True when value is some locale's default (i.e. not customised)
+func isBuiltinPrompt(value string, field func(LocalePrompts) string) bool {
+	for _, locale := range LOCALES {
+		if field(locale.Prompts()) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var swedishTerms = []string{
+	"bästa romantiska ljudböcker", "topp 10 romanceböcker 2025", "obegränsat med romance ljudböcker",
+	"romance e-böcker gratis provperiod", "romance ljudböcker för pendling", "var hittar man bra romance böcker",
+	"hur lyssnar man på romance offline", "storytel mot nextory romance", "alternativ till kindle för romance",
+	"enemies to lovers ljudbok svenska", "feelgood romance för sommaren", "historisk romance uppläsare",
+	"mysig småstadsromance", "romantasy ljudbok tips", "vilken app har flest romance böcker",
+}
+
+func TestEveryLocaleValidatesAndCoversTheBuiltinRules(t *testing.T) {
+	for _, code := range localeCodes() {
+		cfg := defaultConfig()
+		cfg.Locale = code
+		if err := cfg.resolveLocale(); err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: %v", code, err)
+		}
+
+		ids := make(map[string]bool)
+		for _, rule := range LOCALES[code].Patterns {
+			ids[rule.ID] = true
+		}
+		for _, rule := range DEFAULT_PATTERN_RULES {
+			if !ids[rule.ID] {
+				t.Errorf("%s: no %q rule", code, rule.ID)
+			}
+		}
+	}
+}
+
+func TestSwedishTermsPassOnlyUnderSwedishRules(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		want   bool
+	}{{"sv", true}, {"en", false}} {
+		cfg := cassetteTestConfig(t)
+		cfg.Locale = tc.locale
+		if err := cfg.resolveLocale(); err != nil {
+			t.Fatal(err)
+		}
+		agent := NewSearchTermAgent("romantiska böcker", nil, nil, cfg.SearchTerms, nil)
+		agent.record(context.Background(), 0, USAGE_STAGE_INITIAL, swedishTerms, nil)
+
+		best := agent.history[agent.best]
+		if got := agent.targetReached(best); got != tc.want {
+			t.Errorf("%s: target reached = %v (score %.1f, patterns %v), want %v",
+				tc.locale, got, best.Score, best.Quality.Patterns, tc.want)
+		}
+	}
+}
+
+func TestLocaleSwapsOnlyBuiltinPrompts(t *testing.T) {
+	t.Setenv("NX_LANDER_LOCALE", "de")
+	path := writeConfigFile(t, "cfg.toml", `
+[keywords]
+system_prompt = "Mein eigener Prompt"
+`)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Keywords.SystemPrompt != "Mein eigener Prompt" {
+		t.Errorf("custom keyword system prompt replaced: %q", cfg.Keywords.SystemPrompt)
+	}
+	if !strings.Contains(cfg.Keywords.UserPromptTemplate, "German") || !strings.Contains(cfg.SearchTerms.Prompts.InitialUserTemplate, "Hörbücher") {
+		t.Errorf("built-in prompts not localized:\n%s\n%s", cfg.Keywords.UserPromptTemplate, cfg.SearchTerms.Prompts.InitialUserTemplate)
+	}
+
+	// --locale after the file: switching again from German defaults works too
+	cfg.Locale = "nl"
+	if err := cfg.resolveLocale(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cfg.SearchTerms.Prompts.RefinementUserTemplate, "Dutch") {
+		t.Errorf("refinement template not switched to Dutch:\n%s", cfg.SearchTerms.Prompts.RefinementUserTemplate)
+	}
+
+	cfg.Locale = "xx"
+	if err := cfg.resolveLocale(); err == nil || !strings.Contains(err.Error(), "unknown locale") {
+		t.Errorf("err = %v, want unknown locale", err)
+	}
+}
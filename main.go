@@ -1,71 +1,68 @@
 package main
 
 import (
-	"bufio"
-	"context"
 	"fmt"
 	"os"
 	"strings"
-	"time"
 )
 
 var (
-	GLOBAL_AI_MODEL     = KIMI_K2_THINKING.Name()
-	GLOBAL_AI_PROVIDERS = []string{KIMI_K2_THINKING["Google"]}
+	GLOBAL_AI_MODEL     = KIMI_K2_THINKING.Name
+	GLOBAL_AI_PROVIDERS = []string{KIMI_K2_THINKING.mustProvider("Google")}
 )
 
 func main() {
-	fmt.Println("🤖 Landing Page Agent Started")
-
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		fmt.Println("⚠️  OPENROUTER_API_KEY not set, using fallback keywords")
-	}
-
-	fmt.Print("💡 What landing page idea? (e.g., romance books, thriller audiobooks): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	idea, _ := reader.ReadString('\n')
-	idea = strings.TrimSpace(idea)
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-	if idea == "" {
-		fmt.Println("❌ No idea provided")
-		return
+// newBackendFromEnv - LOCAL_LLM_BASE_URL wins over OPENROUTER_API_KEY; nil means offline fallback
+func newBackendFromEnv() LLMBackend {
+	if baseURL := os.Getenv("LOCAL_LLM_BASE_URL"); baseURL != "" {
+		return NewLocalBackend(baseURL, os.Getenv("LOCAL_LLM_API_KEY"), os.Getenv("LOCAL_LLM_MODEL"))
 	}
-
-	fmt.Printf("\n💡 Building landing page for: %s\n", idea)
-	fmt.Println("🔄 Generating SEO keywords...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
-
-	keywords, err := generateKeywords(ctx, apiKey, idea)
-	if err != nil {
-		fmt.Printf("❌ Error generating keywords: %v\n", err)
-		return
+	if apiKey := os.Getenv("OPENROUTER_API_KEY"); apiKey != "" {
+		return NewOpenRouterBackend(apiKey, "BookTok Landing Page Agent")
 	}
+	return nil
+}
 
-	fmt.Println("\n✨ Generated Keywords:")
-	fmt.Println(strings.Repeat("─", 50))
+func printKeywords(keywords []string) {
+	fmt.Fprintln(statusOut, "\n✨ Generated Keywords:")
+	fmt.Fprintln(statusOut, strings.Repeat("─", 50))
 	for i, kw := range keywords {
-		fmt.Printf("  %2d. %s\n", i+1, kw)
+		fmt.Fprintf(statusOut, "  %2d. %s\n", i+1, kw)
 	}
-	fmt.Println(strings.Repeat("─", 50))
-	fmt.Printf("\n📊 Total: %d keywords\n", len(keywords))
+	fmt.Fprintln(statusOut, strings.Repeat("─", 50))
+	fmt.Fprintf(statusOut, "\n📊 Total: %d keywords\n", len(keywords))
+}
 
-	// Generate specific search terms
-	fmt.Println("\n🔍 Generating must-target search terms...")
-	searchTerms, err := generateSearchTerms(ctx, apiKey, idea, keywords)
-	if err != nil {
-		fmt.Printf("❌ Error generating search terms: %v\n", err)
-		return
+func printSearchTerms(records []SearchTermRecord) {
+	fmt.Fprintln(statusOut, "\n🎯 Must-Target Search Terms:")
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	for i, r := range records {
+		fmt.Fprintf(statusOut, "  %2d. %-45s %s · %s · %s\n", i+1, r.Term, r.Intent, r.FunnelStage, r.Format)
 	}
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	fmt.Fprintf(statusOut, "\n🎯 Total: %d search terms\n", len(records))
+}
 
-	fmt.Println("\n🎯 Must-Target Search Terms:")
-	fmt.Println(strings.Repeat("═", 60))
-	for i, term := range searchTerms {
-		fmt.Printf("  %2d. %s\n", i+1, term)
+func printContentBrief(brief ContentBrief) {
+	fmt.Fprintln(statusOut, "\n📝 Content Brief:")
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	fmt.Fprintf(statusOut, "  Title tag:   %s (%d chars, %s)\n", brief.TitleTag.Text, brief.TitleTag.Length, brief.TitleTag.Status)
+	fmt.Fprintf(statusOut, "  Meta:        %d chars, %s\n", brief.MetaDescription.Length, brief.MetaDescription.Status)
+	fmt.Fprintf(statusOut, "  H1:          %s\n", brief.H1)
+	for _, item := range brief.Outline {
+		fmt.Fprintf(statusOut, "  H2:          %s (~%d words)\n", item.Heading, item.WordCount)
+	}
+	fmt.Fprintf(statusOut, "  FAQ:         %d questions\n", len(brief.FAQ))
+	existing := 0
+	for _, link := range brief.InternalLinks {
+		if link.Exists {
+			existing++
+		}
 	}
-	fmt.Println(strings.Repeat("═", 60))
-	fmt.Printf("\n🎯 Total: %d search terms\n", len(searchTerms))
+	fmt.Fprintf(statusOut, "  Links:       %d internal, %d suggested pages\n", existing, len(brief.InternalLinks)-existing)
+	fmt.Fprintf(statusOut, "  Words:       ~%d (%d–%d)\n", brief.WordCount.Target, brief.WordCount.Min, brief.WordCount.Max)
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
 }
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🗺️ MULTI-MARKET RUNS - One Idea, Native Terms per Market
+// ═══════════════════════════════════════════════════════════════════════════
+//
+//   nx-lander-agent markets --idea "romance books" --markets SE,FI,DE,NL
+//
+// Every market is a full pipeline run in that market's locale, so its
+// keywords and search terms are generated natively (not translated). The
+// cross-market report then lines the markets up by CONCEPT:
+//   - pattern families (same rule ids in every locale)
+//   - topics from MARKET_CONCEPTS (one multilingual dictionary entry each)
+//   - literal words several markets share (loanwords, brands, genre names)
+//
+// Budgets and timeouts apply per market, like per theme in batch mode.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	DEFAULT_MARKETS      = "SE,FI,DE,NL"
+	MARKETS_REPORT_FILE  = "markets.json"
+	SHARED_WORD_MIN_SIZE = 5 // Shorter shared words are mostly noise ("top", "with", "this")
+
+	CONCEPT_KIND_PATTERN = "pattern"
+	CONCEPT_KIND_TOPIC   = "topic"
+	CONCEPT_KIND_WORD    = "word"
+)
+
+// MarketConcept - One idea searchers express differently per language (substrings, lowercased)
+type MarketConcept struct {
+	ID    string
+	Terms map[string][]string // Locale code → substrings
+}
+
+// MARKET_CONCEPTS - Topics worth comparing across markets
+var MARKET_CONCEPTS = []MarketConcept{
+	{ID: "audiobooks", Terms: map[string][]string{
+		"en": {"audiobook"}, "sv": {"ljudbok", "ljudböcker"}, "fi": {"äänikirj"}, "de": {"hörbuch", "hörbücher"}, "nl": {"luisterboek"}}},
+	{ID: "ebooks", Terms: map[string][]string{
+		"en": {"ebook", "e-book"}, "sv": {"e-bok", "e-böcker", "ebok"}, "fi": {"e-kirj"}, "de": {"e-book", "ebook"}, "nl": {"e-book", "ebook"}}},
+	{ID: "magazines", Terms: map[string][]string{
+		"en": {"magazine"}, "sv": {"tidning"}, "fi": {"lehti", "lehdet", "lehtiä"}, "de": {"magazin", "zeitschrift"}, "nl": {"tijdschrift"}}},
+	{ID: "free_trial", Terms: map[string][]string{
+		"en": {"free", "trial"}, "sv": {"gratis", "provperiod"}, "fi": {"ilmai", "kokeilu"}, "de": {"kostenlos", "gratis", "probeabo"}, "nl": {"gratis", "proefperiode"}}},
+	{ID: "unlimited", Terms: map[string][]string{
+		"en": {"unlimited"}, "sv": {"obegränsa"}, "fi": {"rajaton", "rajattom"}, "de": {"unbegrenzt", "flatrate"}, "nl": {"onbeperkt"}}},
+	{ID: "kids", Terms: map[string][]string{
+		"en": {"kids", "children"}, "sv": {"barn"}, "fi": {"lapsi", "lapsille", "lasten"}, "de": {"kinder"}, "nl": {"kinderen", "kids"}}},
+	{ID: "family", Terms: map[string][]string{
+		"en": {"family"}, "sv": {"familj"}, "fi": {"perhe"}, "de": {"familie"}, "nl": {"gezin", "familie"}}},
+	{ID: "commute", Terms: map[string][]string{
+		"en": {"commute", "commuting"}, "sv": {"pendling", "pendla"}, "fi": {"työmatk"}, "de": {"arbeitsweg", "pendeln"}, "nl": {"onderweg", "woon-werk"}}},
+	{ID: "sleep", Terms: map[string][]string{
+		"en": {"sleep", "bedtime"}, "sv": {"sova", "somna", "godnatt"}, "fi": {"uni", "nukku"}, "de": {"einschlafen", "schlafen"}, "nl": {"slapen", "inslapen"}}},
+	{ID: "offline", Terms: map[string][]string{
+		"en": {"offline"}, "sv": {"offline"}, "fi": {"offline"}, "de": {"offline"}, "nl": {"offline"}}},
+	{ID: "series", Terms: map[string][]string{
+		"en": {"series"}, "sv": {"serie"}, "fi": {"sarja"}, "de": {"reihe", "serie"}, "nl": {"serie", "reeks"}}},
+	{ID: "new_releases", Terms: map[string][]string{
+		"en": {"new release", "2025", "2026"}, "sv": {"nyheter", "nya ", "2025", "2026"}, "fi": {"uutuu", "uudet", "2025", "2026"}, "de": {"neuerscheinung", "neue ", "2025", "2026"}, "nl": {"nieuwe ", "nieuw ", "2025", "2026"}}},
+}
+
+// MarketResult - One market's pipeline run
+type MarketResult struct {
+	Market string `json:"market"` // "SE"
+	PipelineResult
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	ResultPath string `json:"result_path,omitempty"`
+
+	patterns *PatternSet // The market's resolved rules, for the report
+}
+
+// ConceptCoverage - Which markets express one concept, with an example term each
+type ConceptCoverage struct {
+	Concept  string            `json:"concept"`
+	Kind     string            `json:"kind"` // pattern, topic or word
+	Markets  []string          `json:"markets"`
+	Examples map[string]string `json:"examples"` // Market → first matching keyword/term
+}
+
+// CrossMarketReport - Concepts in every market vs. only some
+type CrossMarketReport struct {
+	Markets  []string          `json:"markets"`  // Markets that produced terms
+	Shared   []ConceptCoverage `json:"shared"`   // In every market
+	Partial  []ConceptCoverage `json:"partial"`  // In more than one, not all
+	Specific []ConceptCoverage `json:"specific"` // In exactly one (patterns and topics only)
+}
+
+// MarketRunSummary - Written to <output>/<idea>/markets.json
+type MarketRunSummary struct {
+	Idea       string            `json:"idea"`
+	DurationMs int64             `json:"duration_ms"`
+	Usage      UsageReport       `json:"usage"`
+	Results    []MarketResult    `json:"results"`
+	Report     CrossMarketReport `json:"report"`
+}
+
+// parseMarkets - "se, FI,de" → [SE FI DE], each mapped to a known locale
+func parseMarkets(list string) ([]string, error) {
+	var markets []string
+	seen := make(map[string]bool)
+	for _, market := range splitList(list) {
+		market = strings.ToUpper(market)
+		if _, err := lookupMarketLocale(market); err != nil {
+			return nil, err
+		}
+		if !seen[market] {
+			seen[market] = true
+			markets = append(markets, market)
+		}
+	}
+	if len(markets) == 0 {
+		return nil, fmt.Errorf("no markets given")
+	}
+	return markets, nil
+}
+
+// lookupMarketLocale - The locale whose Countries include market
+func lookupMarketLocale(market string) (Locale, error) {
+	var known []string
+	for _, code := range localeCodes() {
+		for _, country := range LOCALES[code].Countries {
+			if strings.EqualFold(country, market) {
+				return LOCALES[code], nil
+			}
+			known = append(known, country)
+		}
+	}
+	sort.Strings(known)
+	return Locale{}, fmt.Errorf("unknown market %q (known: %s)", market, strings.Join(known, ", "))
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏃 RUN
+// ═══════════════════════════════════════════════════════════════════════════
+
+// runMarkets - One pipeline per market, in order; a failing market doesn't stop the others
+func runMarkets(ctx context.Context, backend LLMBackend, idea string, markets []string, perMarket time.Duration, cfg Config) MarketRunSummary {
+	start := time.Now()
+	summary := MarketRunSummary{Idea: idea}
+	reports := make([]UsageReport, 0, len(markets))
+
+	for _, market := range markets {
+		result := runMarket(ctx, backend, idea, market, perMarket, cfg)
+		reports = append(reports, result.Usage)
+		summary.Results = append(summary.Results, result)
+	}
+
+	summary.Usage = mergeUsageReports(reports)
+	summary.Report = buildCrossMarketReport(summary.Results)
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary
+}
+
+func runMarket(ctx context.Context, backend LLMBackend, idea, market string, perMarket time.Duration, cfg Config) (result MarketResult) {
+	start := time.Now()
+	result.Market = market
+	result.Theme = idea
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+		if result.Error != "" {
+			log.Printf("❌ [%s] %s", market, result.Error)
+		} else {
+			log.Printf("✅ [%s] %d keywords, %d search terms (%s)", market, len(result.Keywords), len(result.SearchTerms), result.Locale)
+		}
+	}()
+
+	locale, err := lookupMarketLocale(market)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	cfg.Locale = locale.Code
+	if err := cfg.resolveLocale(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.patterns = cfg.SearchTerms.patternSet()
+
+	marketCtx, cancel := context.WithTimeout(ctx, perMarket)
+	defer cancel()
+
+	pipeline, err := runPipeline(marketCtx, backend, idea, cfg)
+	result.PipelineResult = pipeline
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📊 CROSS-MARKET REPORT
+// ═══════════════════════════════════════════════════════════════════════════
+
+// buildCrossMarketReport - Groups concepts by how many successful markets express them
+func buildCrossMarketReport(results []MarketResult) CrossMarketReport {
+	report := CrossMarketReport{Shared: []ConceptCoverage{}, Partial: []ConceptCoverage{}, Specific: []ConceptCoverage{}}
+	coverage := make(map[string]*ConceptCoverage)
+	var order []string
+
+	mark := func(kind, concept, market, example string) {
+		key := kind + ":" + concept
+		c, ok := coverage[key]
+		if !ok {
+			c = &ConceptCoverage{Concept: concept, Kind: kind, Examples: make(map[string]string)}
+			coverage[key] = c
+			order = append(order, key)
+		}
+		if _, done := c.Examples[market]; !done {
+			c.Examples[market] = example
+			c.Markets = append(c.Markets, market)
+		}
+	}
+
+	for _, r := range results {
+		if r.Error != "" || len(r.SearchTerms) == 0 {
+			continue
+		}
+		report.Markets = append(report.Markets, r.Market)
+
+		items := make([]string, 0, len(r.Keywords)+len(r.SearchTerms))
+		for _, item := range append(append([]string(nil), r.SearchTerms...), r.Keywords...) {
+			items = append(items, strings.ToLower(item))
+		}
+
+		// Pattern families: same rule ids in every locale
+		patterns := r.patterns
+		if locale, ok := LOCALES[r.Locale]; ok && patterns == nil {
+			patterns = mustPatternSet(locale.Patterns, nil)
+		} else if patterns == nil {
+			patterns = defaultPatternSet
+		}
+		for _, rule := range patterns.rules {
+			for _, item := range items {
+				if rule.matches(item) {
+					mark(CONCEPT_KIND_PATTERN, rule.ID, r.Market, item)
+					break
+				}
+			}
+		}
+
+		// Topics from the multilingual dictionary
+		for _, concept := range MARKET_CONCEPTS {
+			if example := firstContaining(items, concept.Terms[r.Locale]); example != "" {
+				mark(CONCEPT_KIND_TOPIC, concept.ID, r.Market, example)
+			}
+		}
+
+		// Literal words: only interesting once a second market uses them too
+		for _, item := range items {
+			for _, word := range strings.Fields(item) {
+				word = strings.Trim(word, ".,:;!?\"'()")
+				if len([]rune(word)) >= SHARED_WORD_MIN_SIZE && !THEME_STOP_WORDS[word] {
+					mark(CONCEPT_KIND_WORD, word, r.Market, item)
+				}
+			}
+		}
+	}
+
+	for _, key := range order {
+		c := *coverage[key]
+		switch {
+		case len(report.Markets) > 1 && len(c.Markets) == len(report.Markets):
+			report.Shared = append(report.Shared, c)
+		case len(c.Markets) > 1:
+			report.Partial = append(report.Partial, c)
+		case c.Kind != CONCEPT_KIND_WORD:
+			report.Specific = append(report.Specific, c)
+		}
+	}
+	return report
+}
+
+// firstContaining - First item containing any of the substrings, "" if none
+func firstContaining(items, substrings []string) string {
+	for _, item := range items {
+		for _, s := range substrings {
+			if strings.Contains(item, s) {
+				return item
+			}
+		}
+	}
+	return ""
+}
+
+// writeMarketResults - Per-market result.json under <output>/<idea>/<market>/, then markets.json
+func writeMarketResults(outputDir string, summary *MarketRunSummary) (string, error) {
+	dir := filepath.Join(outputDir, slugify(summary.Idea))
+	for i := range summary.Results {
+		path := filepath.Join(dir, strings.ToLower(summary.Results[i].Market), BATCH_RESULT_FILENAME)
+		if err := writeJSONFile(path, summary.Results[i]); err != nil {
+			return "", err
+		}
+		summary.Results[i].ResultPath = path
+	}
+
+	path := filepath.Join(dir, MARKETS_REPORT_FILE)
+	return path, writeJSONFile(path, summary)
+}
+
+// printCrossMarketReport - Shared / partial / market-specific concepts
+func printCrossMarketReport(report CrossMarketReport) {
+	fmt.Fprintln(statusOut, "\n🗺️  Cross-Market Report:")
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+	section := func(title string, concepts []ConceptCoverage) {
+		fmt.Fprintf(statusOut, "  %s (%d)\n", title, len(concepts))
+		for _, c := range concepts {
+			fmt.Fprintf(statusOut, "    %-8s %-20s %s\n", c.Kind, c.Concept, strings.Join(c.Markets, ", "))
+		}
+	}
+	section("In every market", report.Shared)
+	section("In some markets", report.Partial)
+	section("Market-specific", report.Specific)
+	fmt.Fprintln(statusOut, strings.Repeat("═", 60))
+}
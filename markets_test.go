@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkets(t *testing.T) {
+	markets, err := parseMarkets("se, FI,de,SE ,nl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SE", "FI", "DE", "NL"}; !reflect.DeepEqual(markets, want) {
+		t.Errorf("markets = %v, want %v", markets, want)
+	}
+	if _, err := parseMarkets("SE,XX"); err == nil {
+		t.Error("unknown market XX accepted")
+	}
+}
+
+func TestCrossMarketReportGroupsConceptsByMarket(t *testing.T) {
+	results := []MarketResult{
+		{Market: "SE", PipelineResult: PipelineResult{Locale: "sv", SearchTerms: []string{
+			"bästa romance ljudböcker", "romance e-böcker gratis provperiod", "romance ljudböcker för barn",
+		}}},
+		{Market: "DE", PipelineResult: PipelineResult{Locale: "de", SearchTerms: []string{
+			"beste romance hörbücher", "romance hörbücher kostenlos testen", "romance zeitschrift abo",
+		}}},
+		{Market: "FI", Error: "timed out"},
+	}
+	report := buildCrossMarketReport(results)
+
+	if !reflect.DeepEqual(report.Markets, []string{"SE", "DE"}) {
+		t.Errorf("markets = %v, want the two that succeeded", report.Markets)
+	}
+	find := func(list []ConceptCoverage, kind, concept string) *ConceptCoverage {
+		for i := range list {
+			if list[i].Kind == kind && list[i].Concept == concept {
+				return &list[i]
+			}
+		}
+		return nil
+	}
+
+	for _, shared := range []struct{ kind, concept string }{
+		{CONCEPT_KIND_PATTERN, "best_lists"}, {CONCEPT_KIND_TOPIC, "audiobooks"},
+		{CONCEPT_KIND_TOPIC, "free_trial"}, {CONCEPT_KIND_WORD, "romance"},
+	} {
+		if find(report.Shared, shared.kind, shared.concept) == nil {
+			t.Errorf("%s %q not shared: %+v", shared.kind, shared.concept, report.Shared)
+		}
+	}
+	if c := find(report.Specific, CONCEPT_KIND_TOPIC, "kids"); c == nil || c.Examples["SE"] != "romance ljudböcker för barn" {
+		t.Errorf("kids should be SE-specific with its example, got %+v", c)
+	}
+	if c := find(report.Specific, CONCEPT_KIND_TOPIC, "magazines"); c == nil || !reflect.DeepEqual(c.Markets, []string{"DE"}) {
+		t.Errorf("magazines should be DE-specific, got %+v", c)
+	}
+	if c := find(report.Specific, CONCEPT_KIND_WORD, "zeitschrift"); c != nil {
+		t.Errorf("single-market words should not be reported: %+v", c)
+	}
+}
+
+func TestRunMarketsUsesEachMarketsLocale(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	summary := runMarkets(context.Background(), nil, "romance books", []string{"SE", "NL"}, DEFAULT_TIMEOUT, cfg)
+
+	if len(summary.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(summary.Results))
+	}
+	for i, want := range []string{"sv", "nl"} {
+		r := summary.Results[i]
+		if r.Error != "" || r.Locale != want || len(r.SearchTerms) == 0 {
+			t.Errorf("%s: locale %q, %d terms, error %q; want locale %q", r.Market, r.Locale, len(r.SearchTerms), r.Error, want)
+		}
+	}
+	if cfg.Locale != DEFAULT_LOCALE {
+		t.Errorf("caller's config switched to locale %q", cfg.Locale)
+	}
+}
+
+func TestOfflineMarketsProduceNativeTerms(t *testing.T) {
+	summary := runMarkets(context.Background(), nil, "romance books", []string{"SE", "DE"}, DEFAULT_TIMEOUT, cassetteTestConfig(t))
+	se, de := summary.Results[0], summary.Results[1]
+
+	shared := make(map[string]bool)
+	for _, term := range se.SearchTerms {
+		shared[term] = true
+	}
+	for _, term := range de.SearchTerms {
+		if shared[term] {
+			t.Errorf("%q generated for both SE and DE", term)
+		}
+	}
+	loanwords := map[string]bool{"romance": true, "online": true, "offline": true, "streaming": true}
+	for _, c := range summary.Report.Shared {
+		if c.Kind == CONCEPT_KIND_WORD && !loanwords[c.Concept] {
+			t.Errorf("offline markets share the word %q: %v", c.Concept, c.Examples)
+		}
+	}
+}
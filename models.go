@@ -1,19 +1,169 @@
 package main
 
-type Model map[string]string
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧠 MODEL REGISTRY - Typed Model Specs With Capabilities and Pricing
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Every model a stage can use must be registered: built-ins below, plus any
+// [[models]] entries from the config file (same name = override).
+// Unknown names and typo'd provider aliases fail at startup, not mid-run.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// ModelSpec - What we know about one model
+type ModelSpec struct {
+	Name                   string            `json:"name"`
+	Providers              map[string]string `json:"providers"`                 // Alias → OpenRouter routing slug, e.g. "Google" → "google-vertex"
+	SupportsTools          bool              `json:"supports_tools"`            // Both stages answer through tool calls
+	MaxTokens              int               `json:"max_tokens"`                // Context window
+	PromptCostPerToken     float64           `json:"prompt_cost_per_token"`     // USD
+	CompletionCostPerToken float64           `json:"completion_cost_per_token"` // USD
+	DefaultTemperature     *float32          `json:"default_temperature"`       // Used when a stage leaves temperature unset; nil = server default
+}
+
+// Provider - Routing slug for an alias ("Google"); errors instead of returning ""
+func (m ModelSpec) Provider(alias string) (string, error) {
+	slug, ok := m.Providers[alias]
+	if !ok {
+		return "", fmt.Errorf("model %s has no provider %q (known: %s)", m.Name, alias, strings.Join(m.providerAliases(), ", "))
+	}
+	return slug, nil
+}
+
+// ResolveProviders - Maps aliases to slugs; values that are already slugs pass through
+func (m ModelSpec) ResolveProviders(providers []string) ([]string, error) {
+	resolved := make([]string, 0, len(providers))
+	for _, p := range providers {
+		if slug, ok := m.Providers[p]; ok {
+			resolved = append(resolved, slug)
+			continue
+		}
+		if !m.hasSlug(p) {
+			return nil, fmt.Errorf("model %s has no provider %q (known: %s)", m.Name, p, strings.Join(m.providerAliases(), ", "))
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
 
-func (m Model) Name() string {
-	return m["name"]
+func (m ModelSpec) hasSlug(slug string) bool {
+	for _, s := range m.Providers {
+		if s == slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (m ModelSpec) providerAliases() []string {
+	var aliases []string
+	for alias, slug := range m.Providers {
+		aliases = append(aliases, fmt.Sprintf("%s=%s", alias, slug))
+	}
+	sort.Strings(aliases)
+	return aliases
 }
 
+// Temperature - Stage temperature, or the model default when the stage leaves it unset
+// (nil when neither is set, so the request leaves it to the server)
+func (m ModelSpec) Temperature(stage *float32) *float32 {
+	if stage != nil {
+		return stage
+	}
+	return m.DefaultTemperature
+}
+
+// mustProvider - For package-level defaults only (a typo here is a programming error)
+func (m ModelSpec) mustProvider(alias string) string {
+	slug, err := m.Provider(alias)
+	if err != nil {
+		panic(err)
+	}
+	return slug
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📚 BUILT-IN MODELS (prices: OpenRouter list prices, USD per token)
+// ═══════════════════════════════════════════════════════════════════════════
+
 var (
-	MINIMAX_M2 = Model{
-		"name":    "minimax/minimax-m2",
-		"Minimax": "minimax/fp8",
-		"Google":  "google-vertex",
+	MINIMAX_M2 = ModelSpec{
+		Name: "minimax/minimax-m2",
+		Providers: map[string]string{
+			"Minimax": "minimax/fp8",
+			"Google":  "google-vertex",
+		},
+		SupportsTools:          true,
+		MaxTokens:              204800,
+		PromptCostPerToken:     0.30 / 1e6,
+		CompletionCostPerToken: 1.20 / 1e6,
+		DefaultTemperature:     float32Ptr(1.0),
 	}
-	KIMI_K2_THINKING = Model{
-		"name":   "moonshotai/kimi-k2-thinking",
-		"Google": "google-vertex",
+	KIMI_K2_THINKING = ModelSpec{
+		Name: "moonshotai/kimi-k2-thinking",
+		Providers: map[string]string{
+			"Google": "google-vertex",
+		},
+		SupportsTools:          true,
+		MaxTokens:              262144,
+		PromptCostPerToken:     0.60 / 1e6,
+		CompletionCostPerToken: 2.50 / 1e6,
+		DefaultTemperature:     float32Ptr(1.0),
 	}
+
+	BUILTIN_MODELS = []ModelSpec{MINIMAX_M2, KIMI_K2_THINKING}
 )
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🗃️ REGISTRY
+// ═══════════════════════════════════════════════════════════════════════════
+
+// ModelRegistry - Lookup-by-name with validation
+type ModelRegistry struct {
+	models map[string]ModelSpec
+}
+
+// NewModelRegistry - Built-ins first, then extra specs (same name replaces)
+func NewModelRegistry(extra []ModelSpec) (*ModelRegistry, error) {
+	r := &ModelRegistry{models: make(map[string]ModelSpec)}
+	for _, m := range BUILTIN_MODELS {
+		r.models[m.Name] = m
+	}
+	for i, m := range extra {
+		if m.Name == "" {
+			return nil, fmt.Errorf("models[%d]: name must not be empty", i)
+		}
+		if m.MaxTokens < 0 || m.PromptCostPerToken < 0 || m.CompletionCostPerToken < 0 {
+			return nil, fmt.Errorf("model %s: max_tokens and costs must not be negative", m.Name)
+		}
+		if !validTemperature(m.DefaultTemperature) {
+			return nil, fmt.Errorf("model %s: default_temperature must be within [0, 2], got %g", m.Name, derefFloat32(m.DefaultTemperature))
+		}
+		r.models[m.Name] = m
+	}
+	return r, nil
+}
+
+// Lookup - Errors with the list of known models instead of returning a zero spec
+func (r *ModelRegistry) Lookup(name string) (ModelSpec, error) {
+	m, ok := r.models[name]
+	if !ok {
+		return ModelSpec{}, fmt.Errorf("unknown model %q (known: %s; add it under [[models]] in the config)", name, strings.Join(r.Names(), ", "))
+	}
+	return m, nil
+}
+
+func (r *ModelRegistry) Names() []string {
+	names := make([]string, 0, len(r.models))
+	for name := range r.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStageTemperature(t *testing.T) {
+	spec := ModelSpec{Name: "m", DefaultTemperature: float32Ptr(1.0)}
+
+	if got := spec.Temperature(nil); got == nil || *got != 1.0 {
+		t.Errorf("unset: got %v, want the model default 1.0", got)
+	}
+	if got := spec.Temperature(float32Ptr(0)); got == nil || *got != 0 {
+		t.Errorf("explicit 0: got %v, want 0", got)
+	}
+	if got := spec.Temperature(float32Ptr(0.3)); got == nil || *got != 0.3 {
+		t.Errorf("explicit 0.3: got %v, want 0.3", got)
+	}
+}
+
+func TestZeroTemperatureSurvivesTheWire(t *testing.T) {
+	req := buildChatCompletionRequest(ChatRequest{Model: "m", Temperature: float32Ptr(0)})
+	if req.Temperature == 0 {
+		t.Fatal("temperature 0 would be dropped by omitempty")
+	}
+	if req.Temperature != math.SmallestNonzeroFloat32 {
+		t.Errorf("got %g, want the smallest positive float", req.Temperature)
+	}
+	if got := buildChatCompletionRequest(ChatRequest{Temperature: float32Ptr(0.7)}).Temperature; got != 0.7 {
+		t.Errorf("got %g, want 0.7 unchanged", got)
+	}
+}
+
+func TestUnsetTemperatureIsLeftToTheServer(t *testing.T) {
+	// A custom model without default_temperature, and a stage that leaves it unset
+	registry, err := NewModelRegistry([]ModelSpec{{Name: "custom/model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, _ := registry.Lookup("custom/model")
+	temperature := spec.Temperature(nil)
+	if temperature != nil {
+		t.Fatalf("temperature = %g, want unset", *temperature)
+	}
+
+	req := buildChatCompletionRequest(ChatRequest{Model: "custom/model", Temperature: temperature})
+	data, _ := json.Marshal(req)
+	if strings.Contains(string(data), `"temperature"`) {
+		t.Errorf("request sends a temperature, want it omitted (server default):\n%s", data)
+	}
+}
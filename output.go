@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📤 STRUCTURED OUTPUT - JSON Documents and CSV Exports
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// text: the emoji tables (default, for humans)
+// json: one PipelineResult document (for downstream tooling)
+// csv:  one row per keyword/search term (for SEO planning spreadsheets)
+//
+// In json/csv mode progress messages move to stderr so stdout stays parseable.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	OUTPUT_FORMAT_TEXT = "text"
+	OUTPUT_FORMAT_JSON = "json"
+	OUTPUT_FORMAT_CSV  = "csv"
+)
+
+// statusOut - Where human-readable progress goes (stderr when stdout carries data)
+var statusOut io.Writer = os.Stdout
+
+var CSV_HEADER = []string{
+	"theme", "kind", "rank", "text",
+	"model", "diversity_score", "iterations", "api_calls",
+	"intent", "funnel_stage", "format", "patterns", "source_keyword", // Search term rows only
+}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case OUTPUT_FORMAT_TEXT, OUTPUT_FORMAT_JSON, OUTPUT_FORMAT_CSV:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want %s, %s or %s)", format, OUTPUT_FORMAT_TEXT, OUTPUT_FORMAT_JSON, OUTPUT_FORMAT_CSV)
+}
+
+// writePipelineJSON - Indented JSON document for a single result
+func writePipelineJSON(w io.Writer, result PipelineResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writePipelineCSV - Flattened rows: every keyword and search term with run metadata.
+// Record columns are matched by term, so a record never lands on another term's row.
+func writePipelineCSV(w io.Writer, results []PipelineResult) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSV_HEADER); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		writeRows := func(kind, model string, items []string, records []SearchTermRecord) error {
+			byTerm := make(map[string]SearchTermRecord, len(records))
+			for _, rec := range records {
+				byTerm[rec.Term] = rec
+			}
+			for i, item := range items {
+				row := []string{
+					r.Theme, kind, strconv.Itoa(i + 1), item,
+					model,
+					strconv.FormatFloat(r.Quality.DiversityScore, 'f', 3, 64),
+					strconv.Itoa(r.Iterations),
+					strconv.Itoa(r.APICalls),
+					"", "", "", "", "",
+				}
+				if rec, ok := byTerm[item]; ok {
+					copy(row[8:], []string{rec.Intent, rec.FunnelStage, rec.Format, strings.Join(rec.Patterns, ";"), rec.SourceKeyword})
+				}
+				if err := writer.Write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := writeRows("keyword", r.KeywordModel.Model, r.Keywords, nil); err != nil {
+			return err
+		}
+		if err := writeRows("search_term", r.SearchTermModel.Model, r.SearchTerms, r.TermRecords); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeStructuredOutput - json/csv to path, or stdout when path is empty
+func writeStructuredOutput(format, path string, results []PipelineResult) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case OUTPUT_FORMAT_JSON:
+		if len(results) == 1 {
+			return writePipelineJSON(w, results[0])
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case OUTPUT_FORMAT_CSV:
+		return writePipelineCSV(w, results)
+	}
+	return validateOutputFormat(format)
+}
+
+func formatRouting(r StageRouting) string {
+	s := r.Model
+	if len(r.Providers) > 0 {
+		s += " " + strings.Join(r.Providers, ",")
+	}
+	if r.Fallback {
+		s += " (fallback)"
+	}
+	return s
+}
+
+func printPipelineSummary(result PipelineResult) {
+	fmt.Fprintln(statusOut, "\n📈 Run Summary:")
+	fmt.Fprintln(statusOut, strings.Repeat("─", 50))
+	fmt.Fprintf(statusOut, "  Backend:     %s\n", result.Backend)
+	fmt.Fprintf(statusOut, "  Locale:      %s\n", result.Locale)
+	fmt.Fprintf(statusOut, "  Models:      keywords %s, search terms %s\n",
+		formatRouting(result.KeywordModel), formatRouting(result.SearchTermModel))
+	fmt.Fprintf(statusOut, "  Keywords:    %d, angles %.0f%%, %s\n",
+		result.KeywordQuality.Count, result.KeywordQuality.AngleCoverage*100, result.KeywordStatus)
+	fmt.Fprintf(statusOut, "  Diversity:   %.2f\n", result.Quality.DiversityScore)
+	fmt.Fprintf(statusOut, "  Clusters:    %d, %d page sections\n", len(result.Clusters), len(result.Sections))
+	fmt.Fprintf(statusOut, "  Score:       %.1f / 100\n", result.Score)
+	fmt.Fprintf(statusOut, "  Iterations:  %d refinements, %d API calls\n", result.Iterations, result.APICalls)
+	fmt.Fprintf(statusOut, "  Status:      %s\n", result.Status)
+	if result.TimedOutStage != "" {
+		fmt.Fprintf(statusOut, "  Timed out:   %s\n", result.TimedOutStage)
+	}
+	if result.StopReason != "" {
+		fmt.Fprintf(statusOut, "  Stopped:     %s\n", result.StopReason)
+	}
+	fmt.Fprintf(statusOut, "  Timings:     keywords %dms, search terms %dms, total %dms\n",
+		result.Timings.KeywordsMs, result.Timings.SearchTermsMs, result.Timings.TotalMs)
+	fmt.Fprintln(statusOut, strings.Repeat("─", 50))
+}
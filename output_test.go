@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWritePipelineCSV(t *testing.T) {
+	result := PipelineResult{
+		Theme:       "romance books",
+		Keywords:    []string{"romance audiobooks", "romance books"},
+		SearchTerms: []string{"best romance audiobooks", "where to listen to romance books", "romance novels"},
+		// Out of order and one short: every row still gets its own term's record, or none
+		TermRecords: []SearchTermRecord{
+			{Term: "where to listen to romance books", Intent: INTENT_INFORMATIONAL, Format: FORMAT_ANY, Patterns: []string{"question"}},
+			{Term: "best romance audiobooks", Intent: INTENT_COMMERCIAL, Format: FORMAT_AUDIOBOOK, Patterns: []string{"best", "format"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		results []PipelineResult
+		want    []string // kind|text|intent|format|patterns per data row
+	}{
+		{"no results", nil, nil},
+		{"keywords then aligned terms", []PipelineResult{result}, []string{
+			"keyword|romance audiobooks|||",
+			"keyword|romance books|||",
+			"search_term|best romance audiobooks|commercial|audiobook|best;format",
+			"search_term|where to listen to romance books|informational|any|question",
+			"search_term|romance novels|||",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writePipelineCSV(&buf, tc.results); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(CSV_HEADER, ",") {
+				t.Fatalf("rows = %q, want the header first", rows)
+			}
+
+			var got []string
+			for _, row := range rows[1:] {
+				if len(row) != len(CSV_HEADER) {
+					t.Errorf("row %q has %d columns, want %d", row, len(row), len(CSV_HEADER))
+				}
+				got = append(got, strings.Join([]string{row[1], row[3], row[8], row[10], row[11]}, "|"))
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("rows:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
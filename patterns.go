@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧩 PATTERN RULES - Declarative SEO Pattern Families
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// One rule = one pattern family: how to detect it (regex OR keyword list)
+// and how to ask for it (description + example in "MISSING PATTERNS").
+//
+// The built-in six live in DEFAULT_PATTERN_RULES (English; locales.go has
+// the same ids with native dictionaries). [[search_terms.patterns]]
+// adds rules, replaces a built-in with the same id, or drops one with
+// disabled = true:
+//
+//   [[search_terms.patterns]]
+//   id = "seasonal"
+//   description = "Seasonal"
+//   example = "'X for summer', 'christmas X'"
+//   keywords = ["summer", "winter", "christmas", "holiday"]
+//
+// Required rules must be covered before the target counts as reached;
+// every covered rule adds its weight to the pattern part of the score.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// PatternRule - [[search_terms.patterns]]
+type PatternRule struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"` // "Comparison terms"
+	Example     string   `json:"example"`     // "'X vs Y', 'X alternative'"
+	Regex       string   `json:"regex"`       // Matched against the lowercased term
+	Keywords    []string `json:"keywords"`    // Or: any of these as a substring (case-insensitive)
+	Required    bool     `json:"required"`
+	Weight      *float64 `json:"weight"`   // Unset = 1
+	Disabled    bool     `json:"disabled"` // Drops the built-in rule with this id
+}
+
+// DEFAULT_PATTERN_RULES - The six families the agent always knew about
+var DEFAULT_PATTERN_RULES = []PatternRule{
+	{
+		ID: "comparisons", Description: "Comparison terms", Example: "'X vs Y', 'X alternative'",
+		Keywords: []string{" vs ", " versus ", "alternative", "comparison"},
+	},
+	{
+		ID: "questions", Description: "Question-based", Example: "'where to find X', 'how to get X'",
+		Regex: `^(where|how|what|which) `,
+	},
+	{
+		ID: "best_lists", Description: "Best/Top lists", Example: "'best X for Y', 'top X in 2025'",
+		Keywords: []string{"best ", "top ", "most popular"},
+	},
+	{
+		ID: "value_terms", Description: "Value-focused", Example: "'unlimited X', 'free X trial'",
+		Keywords: []string{"unlimited", "free", "trial", "affordable"},
+	},
+	{
+		ID: "format_mix", Description: "Format combinations", Example: "'X audiobooks', 'X ebooks'",
+		Keywords: []string{"audiobook", "ebook", "book", "magazine"},
+		Weight:   float64Ptr(0.5), // Nearly every term mentions books somehow
+	},
+	{
+		ID: "user_intent", Description: "User intent", Example: "'X for beginners', 'X for commute'",
+		Keywords: []string{" for "},
+	},
+}
+
+// compiledPattern - A rule ready to match
+type compiledPattern struct {
+	PatternRule
+	regex    *regexp.Regexp
+	keywords []string // Lowercased
+	weight   float64
+}
+
+// PatternSet - The resolved, compiled rules in prompt order
+type PatternSet struct {
+	rules []compiledPattern
+}
+
+// defaultPatternSet - Built-ins only; used when a config was never resolved (tests, defaults)
+var defaultPatternSet = mustPatternSet(DEFAULT_PATTERN_RULES, nil)
+
+func mustPatternSet(base, overrides []PatternRule) *PatternSet {
+	set, err := NewPatternSet(base, overrides)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// NewPatternSet - base (a locale's built-in rules) merged with overrides by id, then compiled
+func NewPatternSet(base, overrides []PatternRule) (*PatternSet, error) {
+	rules := append([]PatternRule(nil), base...)
+	seen := make(map[string]bool)
+
+	for i, rule := range overrides {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("search_terms.patterns[%d]: id must not be empty", i)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("search_terms.patterns[%d]: duplicate id %q", i, rule.ID)
+		}
+		seen[rule.ID] = true
+
+		replaced := false
+		for j := range rules {
+			if rules[j].ID == rule.ID {
+				rules[j], replaced = rule, true
+				break
+			}
+		}
+		if !replaced {
+			rules = append(rules, rule)
+		}
+	}
+
+	set := &PatternSet{}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		compiled, err := compilePatternRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("search_terms.patterns %q: %w", rule.ID, err)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+func compilePatternRule(rule PatternRule) (compiledPattern, error) {
+	compiled := compiledPattern{PatternRule: rule, weight: 1}
+	if rule.Weight != nil {
+		compiled.weight = *rule.Weight
+	}
+
+	switch {
+	case rule.Description == "":
+		return compiled, fmt.Errorf("description must not be empty")
+	case (rule.Regex == "") == (len(rule.Keywords) == 0):
+		return compiled, fmt.Errorf("set exactly one of regex or keywords")
+	case compiled.weight < 0:
+		return compiled, fmt.Errorf("weight must not be negative, got %g", compiled.weight)
+	}
+
+	if rule.Regex != "" {
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return compiled, fmt.Errorf("bad regex: %w", err)
+		}
+		compiled.regex = re
+	}
+	for _, keyword := range rule.Keywords {
+		compiled.keywords = append(compiled.keywords, strings.ToLower(keyword))
+	}
+	return compiled, nil
+}
+
+// matches - termLower is already lowercased
+func (p compiledPattern) matches(termLower string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(termLower)
+	}
+	for _, keyword := range p.keywords {
+		if strings.Contains(termLower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len - Number of enabled rules
+func (s *PatternSet) Len() int {
+	return len(s.rules)
+}
+
+// Matching - Ids of the rules one term matches, in rule order
+func (s *PatternSet) Matching(termLower string) []string {
+	var ids []string
+	for _, rule := range s.rules {
+		if rule.matches(termLower) {
+			ids = append(ids, rule.ID)
+		}
+	}
+	return ids
+}
+
+// Detect - Which rules any term covers (every rule id is present)
+// plus the covered share of the total rule weight (0-1)
+func (s *PatternSet) Detect(termsLower []string) (map[string]bool, float64) {
+	covered := make(map[string]bool, len(s.rules))
+	var total, hit float64
+	for _, rule := range s.rules {
+		covered[rule.ID] = false
+		total += rule.weight
+		for _, term := range termsLower {
+			if rule.matches(term) {
+				covered[rule.ID] = true
+				hit += rule.weight
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return covered, 0
+	}
+	return covered, hit / total
+}
+
+// MissingRequired - Ids of required rules not covered
+func (s *PatternSet) MissingRequired(covered map[string]bool) []string {
+	var missing []string
+	for _, rule := range s.rules {
+		if rule.Required && !covered[rule.ID] {
+			missing = append(missing, rule.ID)
+		}
+	}
+	return missing
+}
+
+// DescribeMissing - The "MISSING PATTERNS" lines for uncovered rules, required first
+func (s *PatternSet) DescribeMissing(covered map[string]bool) []string {
+	var required, optional []string
+	for _, rule := range s.rules {
+		if covered[rule.ID] {
+			continue
+		}
+		line := "- " + rule.Description
+		if rule.Example != "" {
+			line += fmt.Sprintf(" (e.g., %s)", rule.Example)
+		}
+		if rule.Required {
+			required = append(required, line+" [REQUIRED]")
+		} else {
+			optional = append(optional, line)
+		}
+	}
+	return append(required, optional...)
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestConfigPatternRulesDriveDetectionAndPrompt(t *testing.T) {
+	path := writeConfigFile(t, "cfg.toml", `
+[search_terms]
+min_pattern_coverage = 6
+
+[[search_terms.patterns]]
+id = "seasonal"
+description = "Seasonal"
+example = "'X for summer', 'christmas X'"
+keywords = ["Summer", "christmas"]
+required = true
+
+[[search_terms.patterns]]
+id = "questions"
+description = "Questions"
+regex = "^(where|how|what|which|why) "
+
+[[search_terms.patterns]]
+id = "format_mix"
+disabled = true
+`)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := NewSearchTermAgent("romance books", nil, nil, cfg.SearchTerms, nil)
+
+	// Covers every remaining built-in rule, but not the required seasonal one
+	agent.record(context.Background(), 0, USAGE_STAGE_INITIAL, cassetteRefinedTerms, nil)
+	best := agent.history[agent.best]
+	if _, ok := best.Quality.Patterns["format_mix"]; ok {
+		t.Error("disabled rule format_mix was still evaluated")
+	}
+	if agent.targetReached(best) {
+		t.Errorf("target reached without the required seasonal pattern (score %.1f)", best.Score)
+	}
+	missing := agent.identifyMissingPatterns(best.Quality)
+	if missing != "- Seasonal (e.g., 'X for summer', 'christmas X') [REQUIRED]" {
+		t.Errorf("missing patterns = %q", missing)
+	}
+
+	seasonal := append([]string{"best summer romance audiobooks", "why christmas romance works"}, cassetteRefinedTerms[2:]...)
+	agent.iteration++
+	if !agent.record(context.Background(), 1, "search_terms.refinement_1", seasonal, nil) {
+		t.Fatalf("seasonal set did not beat the first: %+v", agent.history)
+	}
+	if best := agent.history[agent.best]; !best.Quality.Patterns["seasonal"] || !agent.targetReached(best) {
+		t.Errorf("seasonal set: patterns %v, score %.1f, want the target reached", best.Quality.Patterns, best.Score)
+	}
+}
+
+func TestInvalidPatternRulesFailAtLoad(t *testing.T) {
+	for name, rule := range map[string]string{
+		"bad regex":    "id = \"x\"\ndescription = \"X\"\nregex = \"(\"",
+		"no matcher":   "id = \"x\"\ndescription = \"X\"",
+		"both":         "id = \"x\"\ndescription = \"X\"\nregex = \"x\"\nkeywords = [\"x\"]",
+		"missing id":   "description = \"X\"\nkeywords = [\"x\"]",
+		"negative wgt": "id = \"x\"\ndescription = \"X\"\nkeywords = [\"x\"]\nweight = -1",
+	} {
+		path := writeConfigFile(t, "cfg.toml", "[[search_terms.patterns]]\n"+rule+"\n")
+		_, err := loadConfig(path)
+		if err == nil || !strings.Contains(err.Error(), "search_terms.patterns") {
+			t.Errorf("%s: err = %v, want a search_terms.patterns error", name, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🚰 PIPELINE STAGES - Keywords → Search Terms → Clusters → Landing Page
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Each stage can run on its own (CLI subcommands) or chained (run, batch).
+// A nil backend means offline: the deterministic fallback generators are used.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const OFFLINE_BACKEND_NAME = "offline"
+
+// PipelineResult - Everything one idea produced (the JSON output document)
+type PipelineResult struct {
+	Theme           string                `json:"theme"`
+	Locale          string                `json:"locale"`
+	Keywords        []string              `json:"keywords"`
+	KeywordQuality  KeywordQuality        `json:"keyword_quality"`
+	KeywordStatus   string                `json:"keyword_status"` // Why keyword refinement stopped (KEYWORD_STATUS_*)
+	SearchTerms     []string              `json:"search_terms"`
+	TermRecords     []SearchTermRecord    `json:"search_term_records"` // Intent, funnel stage, patterns, format, source keyword per term
+	Clusters        []TermCluster         `json:"clusters"`
+	Sections        []PageSection         `json:"page_sections"` // Clusters mapped onto hero, H2 blocks and FAQ
+	Quality         SearchTermQuality     `json:"quality"`
+	Score           float64               `json:"score"`
+	Iterations      int                   `json:"iterations"`
+	APICalls        int                   `json:"api_calls"`
+	Status          string                `json:"status"` // Why search term refinement stopped (SEARCH_TERM_STATUS_*)
+	StopReason      string                `json:"stop_reason,omitempty"`
+	TimedOutStage   string                `json:"timed_out_stage,omitempty"` // keywords, search_terms, search_terms.refinement_2, ...
+	Backend         string                `json:"backend"`
+	KeywordModel    StageRouting          `json:"keyword_model"`
+	SearchTermModel StageRouting          `json:"search_term_model"`
+	Timings         PipelineTimings       `json:"timings"`
+	Usage           UsageReport           `json:"usage"`
+	Trace           []SearchTermCandidate `json:"trace,omitempty"` // Every search term candidate, for debugging
+	LandingPagePath string                `json:"landing_page_path,omitempty"`
+	BriefPath       string                `json:"brief_path,omitempty"` // content_brief.md (the .json sits next to it)
+}
+
+// StageRouting - Which model/providers a stage used (the configured ones until a call succeeds)
+type StageRouting struct {
+	Model     string   `json:"model"`
+	Providers []string `json:"providers,omitempty"`
+	Fallback  bool     `json:"fallback,omitempty"` // Answered by a [[<stage>.fallbacks]] entry
+}
+
+// PipelineTimings - Wall-clock per stage, in milliseconds
+type PipelineTimings struct {
+	KeywordsMs    int64 `json:"keywords_ms"`
+	SearchTermsMs int64 `json:"search_terms_ms"`
+	LandingPageMs int64 `json:"landing_page_ms,omitempty"`
+	TotalMs       int64 `json:"total_ms"`
+}
+
+// runPipeline - Keywords then search terms; the caller decides what to do with the page
+func runPipeline(ctx context.Context, backend LLMBackend, idea string, cfg Config) (result PipelineResult, err error) {
+	start := time.Now()
+	result = PipelineResult{
+		Theme:           idea,
+		Locale:          cfg.Locale,
+		Backend:         backendName(backend),
+		KeywordModel:    stageRouting(backend, cfg.Keywords.StageSettings),
+		SearchTermModel: stageRouting(backend, cfg.SearchTerms.StageSettings),
+	}
+	usage := NewUsageTracker(cfg.registry)
+	defer func() { result.Usage = usage.Report() }() // Failed runs still cost money
+	budget := NewBudget(cfg.Budget, usage)
+
+	keywords, err := runKeywordStage(ctx, backend, idea, cfg, usage, budget)
+	result.Timings.KeywordsMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.TimedOutStage = timedOutStage(err)
+		return result, err
+	}
+	result.Keywords = keywords.Keywords
+	result.KeywordQuality = keywords.Quality
+	result.KeywordStatus = keywords.Status
+	result.TimedOutStage = keywords.TimedOutStage
+	if keywords.Routing != nil {
+		result.KeywordModel = *keywords.Routing
+	}
+
+	stageStart := time.Now()
+	searchTerms, err := runSearchTermStage(ctx, backend, idea, result.Keywords, cfg, usage, budget)
+	result.Timings.SearchTermsMs = time.Since(stageStart).Milliseconds()
+	if err != nil {
+		result.TimedOutStage = timedOutStage(err)
+		return result, err
+	}
+	result.SearchTerms = searchTerms.Terms
+	result.TermRecords = searchTerms.Records
+	result.Quality = searchTerms.Quality
+	result.Iterations = searchTerms.Iterations
+	result.Score = searchTerms.Score
+	result.Trace = searchTerms.Trace
+	result.APICalls = searchTerms.APICalls
+	result.Status = searchTerms.Status
+	result.StopReason = searchTerms.StopReason
+	if searchTerms.TimedOutStage != "" {
+		result.TimedOutStage = searchTerms.TimedOutStage
+	}
+	if searchTerms.Routing != nil {
+		result.SearchTermModel = *searchTerms.Routing
+	}
+	result.APICalls += keywords.APICalls
+
+	clusters, err := runClusterStage(ctx, backend, idea, result.TermRecords, cfg, usage, budget)
+	if err != nil {
+		return result, err
+	}
+	result.Clusters = clusters.Clusters
+	result.Sections = clusters.Sections
+	result.APICalls += clusters.APICalls
+
+	result.Timings.TotalMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// KeywordStageResult - Keywords plus the route that actually answered (nil offline)
+type KeywordStageResult struct {
+	KeywordResult
+	Routing       *StageRouting
+	TimedOutStage string // Set when the stage deadline fired and the keywords are the fallback ones
+}
+
+// runKeywordStage - Each stage gets its own deadline and retry/fallback chain around the shared backend.
+// Metering and per-call deadlines sit inside the chain, so every attempt is timed and priced on its own.
+// When only the stage's own deadline fired, the run continues on fallback keywords.
+func runKeywordStage(ctx context.Context, backend LLMBackend, idea string, cfg Config, usage *UsageTracker, budget *Budget) (KeywordStageResult, error) {
+	if backend == nil {
+		return fallbackKeywordStage(idea, cfg, KEYWORD_STATUS_OFFLINE), nil
+	}
+	if err := budget.CheckNextCall(); err != nil {
+		return KeywordStageResult{}, fmt.Errorf("keyword stage: %w", err)
+	}
+	stageCtx, cancel := withTimeoutMs(ctx, cfg.Timeouts.KeywordsMs)
+	defer cancel()
+
+	backend = NewMeteredBackend(backend, usage, USAGE_STAGE_KEYWORDS)
+	backend = NewCallTimeoutBackend(backend, cfg.Timeouts.CallMs)
+	backend = NewRetryingBackend(backend, cfg.Retry, cfg.Keywords.Fallbacks)
+	keywords, err := generateKeywords(stageCtx, backend, idea, cfg.Keywords, budget)
+	if err != nil {
+		err = asStageTimeout(USAGE_STAGE_KEYWORDS, err)
+		if timedOutStage(err) != "" && ctx.Err() == nil {
+			log.Printf("⏱️  Keyword stage ran out of time, continuing with fallback keywords: %v", err)
+			result := fallbackKeywordStage(idea, cfg, KEYWORD_STATUS_TIMED_OUT)
+			result.TimedOutStage = USAGE_STAGE_KEYWORDS
+			return result, nil
+		}
+		return KeywordStageResult{}, fmt.Errorf("keyword stage: %w", err)
+	}
+	result := KeywordStageResult{KeywordResult: keywords}
+	if route, ok := answeredRoute(backend); ok {
+		result.Routing = &route
+	}
+	return result, nil
+}
+
+// fallbackKeywordStage - Template keywords for the locale, with the given status
+func fallbackKeywordStage(idea string, cfg Config, status string) KeywordStageResult {
+	keywords := generateFallbackKeywords(idea, cfg.Keywords.Count, localeFor(cfg.Locale))
+	return KeywordStageResult{KeywordResult: KeywordResult{
+		Keywords: keywords,
+		Quality:  evaluateKeywordQuality(keywords, idea, cfg.Keywords),
+		Status:   status,
+	}}
+}
+
+func runSearchTermStage(ctx context.Context, backend LLMBackend, idea string, keywords []string, cfg Config, usage *UsageTracker, budget *Budget) (SearchTermResult, error) {
+	if backend == nil {
+		terms := generateFallbackSearchTerms(idea, keywords, cfg.SearchTerms.Count, localeFor(cfg.Locale))
+		terms, _ = dedupeTerms(terms, cfg.SearchTerms.DuplicateSimilarity, cfg.SearchTerms.stopWordSet())
+		embedder, _ := cfg.SearchTerms.Embeddings.New()
+		quality := assessSearchTerms(ctx, terms, idea, cfg.SearchTerms, embedder)
+		return SearchTermResult{
+			Terms:   terms,
+			Records: buildTermRecords(terms, nil, keywords, cfg.SearchTerms.patternSet(), cfg.SearchTerms.stopWordSet()),
+			Quality: quality,
+			Score:   cfg.SearchTerms.Scoring.Score(quality, cfg.SearchTerms.Count),
+			Status:  SEARCH_TERM_STATUS_OFFLINE,
+		}, nil
+	}
+	ctx, cancel := withTimeoutMs(ctx, cfg.Timeouts.SearchTermsMs)
+	defer cancel()
+
+	backend = NewMeteredBackend(backend, usage, USAGE_STAGE_INITIAL)
+	backend = NewCallTimeoutBackend(backend, cfg.Timeouts.CallMs)
+	backend = NewRetryingBackend(backend, cfg.Retry, cfg.SearchTerms.Fallbacks)
+	result, err := generateSearchTerms(ctx, backend, idea, keywords, cfg.SearchTerms, budget)
+	if err != nil {
+		return SearchTermResult{}, fmt.Errorf("search term stage: %w", err)
+	}
+	if route, ok := answeredRoute(backend); ok {
+		result.Routing = &route
+	}
+	return result, nil
+}
+
+func backendName(backend LLMBackend) string {
+	if backend == nil {
+		return OFFLINE_BACKEND_NAME
+	}
+	return backend.Name()
+}
+
+func stageRouting(backend LLMBackend, settings StageSettings) StageRouting {
+	if backend == nil {
+		return StageRouting{Model: OFFLINE_BACKEND_NAME}
+	}
+	return StageRouting{Model: settings.Model, Providers: settings.Providers}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🩹 RESPONSE REPAIR - Salvage Lists From Sloppy Model Output
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Models don't always answer the way the tool schema says. Instead of
+// throwing away a paid call, repairToolList tries, in order:
+//   1. The tool arguments as JSON ({"search_terms": [...]} or a bare [...])
+//   2. The same with ```json fences stripped
+//   3. Truncated JSON: every complete string before the cut
+//   4. The message content instead of a tool call (JSON or a bullet/numbered list)
+//
+// Fixing the COUNT is the caller's job (SearchTermAgent.collectTerms):
+// too many → trim by score, too few → one top-up call for only the missing N.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// Repair notes (RepairedList.Repairs, SearchTermCandidate.Repairs)
+const (
+	REPAIR_CONTENT   = "content"   // Read from the message content, not a tool call
+	REPAIR_FENCED    = "fenced"    // Markdown code fence stripped
+	REPAIR_TRUNCATED = "truncated" // JSON cut off; complete items kept
+	REPAIR_FIELD     = "field"     // List found under another property name
+	REPAIR_TEXT_LIST = "text_list" // Bullet or numbered lines, no JSON at all
+	REPAIR_TRIMMED   = "trimmed"   // Too many items; the lowest-value ones dropped
+	REPAIR_TOPPED_UP = "topped_up" // Too few items; the rest came from a top-up call
+)
+
+var (
+	CODE_FENCE_REGEX = regexp.MustCompile("(?s)```[a-zA-Z]*[ \t]*\n?(.*?)(?:```|$)")
+	LIST_LINE_REGEX  = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+(.+?)\s*$`)
+)
+
+// RepairedList - The items pulled out of a response, and what it took to get them
+type RepairedList struct {
+	Items   []string
+	Fields  []map[string]string // Per item: its other string properties when the model sent objects (nil for plain strings)
+	Repairs []string
+}
+
+// LIST_ITEM_TEXT_KEYS - Where an object item keeps its text ({"term": "...", "intent": "..."})
+var LIST_ITEM_TEXT_KEYS = []string{"term", "text", "keyword", "search_term"}
+
+// add - One item, trimmed and unquoted; empty ones are skipped
+func (l *RepairedList) add(item string, fields map[string]string) {
+	item = strings.TrimSpace(strings.Trim(strings.TrimSpace(item), `"'`+"`"))
+	if item != "" {
+		l.Items = append(l.Items, item)
+		l.Fields = append(l.Fields, fields)
+	}
+}
+
+// repairToolList - The string list under field, from wherever the response put it
+func repairToolList(resp ChatResponse, field string) (RepairedList, error) {
+	if resp.HasToolCall() {
+		if list, ok := parseList(resp.ToolArguments, field); ok {
+			return list, nil
+		}
+	}
+	if strings.TrimSpace(resp.Content) != "" {
+		if list, ok := parseList(resp.Content, field); ok {
+			list.Repairs = append([]string{REPAIR_CONTENT}, list.Repairs...)
+			return list, nil
+		}
+		if list := parseTextList(resp.Content); len(list.Items) > 0 {
+			list.Repairs = []string{REPAIR_CONTENT, REPAIR_TEXT_LIST}
+			return list, nil
+		}
+	}
+
+	if !resp.HasToolCall() {
+		return RepairedList{}, fmt.Errorf("no tool call and no %s list in the message content", field)
+	}
+	return RepairedList{}, fmt.Errorf("no %s list in tool arguments %s", field, clip(resp.ToolArguments, 80))
+}
+
+// parseList - JSON (possibly fenced or truncated) holding a non-empty list of strings or term objects
+func parseList(text, field string) (RepairedList, bool) {
+	var repairs []string
+	if m := CODE_FENCE_REGEX.FindStringSubmatch(text); m != nil {
+		text = m[1]
+		repairs = append(repairs, REPAIR_FENCED)
+	}
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return RepairedList{}, false
+	}
+	text = strings.TrimSpace(text[start:])
+
+	var list RepairedList
+	var object map[string]json.RawMessage
+	switch {
+	case decodeList([]byte(text), &list):
+	case json.Unmarshal([]byte(text), &object) == nil:
+		if !decodeList(object[field], &list) {
+			keys := make([]string, 0, len(object))
+			for k := range object {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				// Any other list (e.g. "terms", "keywords")
+				if decodeList(object[k], &list) {
+					repairs = append(repairs, REPAIR_FIELD)
+					break
+				}
+			}
+		}
+	default:
+		list = salvageList(text, field)
+		repairs = append(repairs, REPAIR_TRUNCATED)
+	}
+
+	list.Repairs = repairs
+	return list, len(list.Items) > 0
+}
+
+// decodeList - A JSON array of strings and/or term objects; false (list untouched) for anything else
+func decodeList(raw json.RawMessage, list *RepairedList) bool {
+	var elements []json.RawMessage
+	if json.Unmarshal(raw, &elements) != nil || len(elements) == 0 {
+		return false
+	}
+	var decoded RepairedList
+	for _, element := range elements {
+		var item string
+		if json.Unmarshal(element, &item) == nil {
+			decoded.add(item, nil)
+			continue
+		}
+		var properties map[string]any
+		if json.Unmarshal(element, &properties) != nil {
+			return false
+		}
+		fields := make(map[string]string)
+		for key, value := range properties {
+			if s, ok := value.(string); ok {
+				fields[key] = s
+			}
+		}
+		item, fields = splitItemText(fields)
+		decoded.add(item, fields)
+	}
+	if len(decoded.Items) == 0 {
+		return false
+	}
+	*list = decoded
+	return true
+}
+
+// splitItemText - An object item's text (first of LIST_ITEM_TEXT_KEYS) and its remaining fields
+func splitItemText(fields map[string]string) (string, map[string]string) {
+	for _, key := range LIST_ITEM_TEXT_KEYS {
+		if text, ok := fields[key]; ok {
+			delete(fields, key)
+			return text, fields
+		}
+	}
+	return "", fields
+}
+
+// salvageList - Complete items (strings or objects) of the root array, or of field in the
+// root object, up to where the JSON breaks off
+func salvageList(text, field string) RepairedList {
+	dec := json.NewDecoder(strings.NewReader(text))
+	var list RepairedList
+	depth, listDepth := 0, 0
+	inObject, expectKey := false, false
+	key := ""
+	var item map[string]string // Object item being read (depth == listDepth+1)
+	itemKey := ""
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return list
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				depth++
+				switch {
+				case depth == 1 && t == '{':
+					inObject, expectKey = true, true
+				case t == '[' && (depth == 1 || (depth == 2 && inObject && key == field)):
+					listDepth = depth
+				case t == '{' && listDepth > 0 && depth == listDepth+1:
+					item, itemKey = make(map[string]string), ""
+				}
+			default:
+				if depth == listDepth {
+					return list
+				}
+				if item != nil && depth == listDepth+1 {
+					text, fields := splitItemText(item)
+					list.add(text, fields)
+					item = nil
+				}
+				depth--
+				if depth == 1 && inObject {
+					expectKey = true
+				}
+				if item != nil && depth == listDepth+1 {
+					itemKey = "" // Nested value inside an item: skipped
+				}
+			}
+		case string:
+			switch {
+			case depth == 1 && inObject && expectKey:
+				key, expectKey = t, false
+			case depth == 1 && inObject:
+				expectKey = true
+			case listDepth > 0 && depth == listDepth:
+				list.add(t, nil)
+			case item != nil && depth == listDepth+1 && itemKey == "":
+				itemKey = t
+			case item != nil && depth == listDepth+1:
+				item[itemKey], itemKey = t, ""
+			}
+		default:
+			if depth == 1 && inObject {
+				expectKey = true
+			}
+			if item != nil && depth == listDepth+1 {
+				itemKey = "" // Non-string value: skipped
+			}
+		}
+	}
+}
+
+// parseTextList - "- term", "* term", "1. term" and "1) term" lines
+func parseTextList(text string) RepairedList {
+	var list RepairedList
+	for _, line := range strings.Split(text, "\n") {
+		if m := LIST_LINE_REGEX.FindStringSubmatch(line); m != nil {
+			list.add(strings.TrimRight(m[1], ","), nil)
+		}
+	}
+	return list
+}
+
+// clip - At most n runes of s, for error messages
+func clip(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRepairToolListSalvagesSloppyResponses(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		resp    ChatResponse
+		want    []string
+		repairs string
+	}{
+		{"clean", ChatResponse{ToolArguments: `{"search_terms": ["a b", "c d"]}`}, []string{"a b", "c d"}, ""},
+		{"fenced", ChatResponse{ToolArguments: "```json\n{\"search_terms\": [\"a b\", \"c d\"]}\n```"}, []string{"a b", "c d"}, REPAIR_FENCED},
+		{"truncated", ChatResponse{ToolArguments: `{"notes": "x", "search_terms": ["a b", "c d", "e f`}, []string{"a b", "c d"}, REPAIR_TRUNCATED},
+		{"truncated objects", ChatResponse{ToolArguments: `{"search_terms": [{"term": "a b", "tags": ["x"], "intent": "commercial"}, {"term": "c d", "intent": "infor`}, []string{"a b"}, REPAIR_TRUNCATED},
+		{"other field", ChatResponse{ToolArguments: `{"terms": ["a b"]}`}, []string{"a b"}, REPAIR_FIELD},
+		{"content json", ChatResponse{Content: "Here you go:\n```\n[\"a b\", \"c d\"]\n```"}, []string{"a b", "c d"}, REPAIR_CONTENT + "," + REPAIR_FENCED},
+		{"content list", ChatResponse{Content: "Sure! Terms:\n1. \"a b\"\n2) c d,\n- e f\nHope this helps."}, []string{"a b", "c d", "e f"}, REPAIR_CONTENT + "," + REPAIR_TEXT_LIST},
+	} {
+		list, err := repairToolList(tc.resp, "search_terms")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if strings.Join(list.Items, "|") != strings.Join(tc.want, "|") || strings.Join(list.Repairs, ",") != tc.repairs {
+			t.Errorf("%s: items %q repairs %q, want %q %q", tc.name, list.Items, list.Repairs, tc.want, tc.repairs)
+		}
+	}
+
+	if _, err := repairToolList(ChatResponse{Content: "I cannot help with that."}, "search_terms"); err == nil {
+		t.Error("prose without a list was accepted")
+	}
+}
+
+func TestShortResponseIsToppedUpWithOnlyTheMissingTerms(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.SearchTerms.MaxRefinementIterations = 0
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: cassetteRefinedTerms[:11]},
+		{terms: cassetteRefinedTerms[11:]},
+	}}
+
+	agent := NewSearchTermAgent("romance books", []string{"romance books"}, backend, cfg.SearchTerms, nil)
+	terms, err := agent.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(terms, "|") != strings.Join(cassetteRefinedTerms, "|") {
+		t.Errorf("terms = %q, want all 15", terms)
+	}
+
+	topUp := backend.requests[1]
+	if !strings.Contains(topUp.UserPrompt, "Add EXACTLY 4 NEW search terms") || !strings.Contains(topUp.UserPrompt, cassetteRefinedTerms[10]) {
+		t.Errorf("top-up prompt:\n%s", topUp.UserPrompt)
+	}
+	result := agent.Result()
+	if result.APICalls != 2 || strings.Join(result.Trace[0].Repairs, ",") != REPAIR_TOPPED_UP {
+		t.Errorf("api calls %d, repairs %q", result.APICalls, result.Trace[0].Repairs)
+	}
+}
+
+func TestOverlongResponseIsTrimmedByScore(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	agent := NewSearchTermAgent("romance books", nil, nil, cfg.SearchTerms, nil)
+
+	padded := append(append([]string(nil), cassetteRefinedTerms...), "best romance audiobook", "Free Romance eBook Trial", "unlimited romance audio books")
+	terms := agent.trimByScore(context.Background(), padded, cfg.SearchTerms.Count)
+	if strings.Join(terms, "|") != strings.Join(cassetteRefinedTerms, "|") {
+		t.Errorf("trimmed = %q, want the three later copies dropped", terms)
+	}
+
+	// Exact copies: the later ones go, the original keeps its place; every term is embedded once
+	embedder := &countingEmbedder{HashedNGramEmbedder: HashedNGramEmbedder{Dimensions: DEFAULT_EMBEDDING_DIMENSIONS, N: DEFAULT_EMBEDDING_NGRAM}}
+	agent.embedder = embedder
+	copies := append(append([]string(nil), cassetteRefinedTerms...), cassetteRefinedTerms[0], cassetteRefinedTerms[0], "romance novels for the beach")
+	terms = agent.trimByScore(context.Background(), copies, cfg.SearchTerms.Count)
+	if len(terms) != cfg.SearchTerms.Count || terms[0] != cassetteRefinedTerms[0] || strings.Count(strings.Join(terms, "|"), cassetteRefinedTerms[0]+"|") != 1 {
+		t.Errorf("trimmed = %q, want both later copies dropped", terms)
+	}
+	if embedder.texts != len(cassetteRefinedTerms)+1 {
+		t.Errorf("embedded %d texts, want each of the %d distinct terms once", embedder.texts, len(cassetteRefinedTerms)+1)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🔁 RETRY + FALLBACK - Survive Flaky Providers
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// For each stage the chain is: primary model → fallbacks (in config order).
+// Each link gets up to MaxAttempts tries with exponential backoff + jitter,
+// but ONLY for retryable errors (429, 408, 5xx, timeouts, dropped connections).
+// Fatal errors (400/401/403/404...) skip straight to the next link.
+// A cancelled/expired parent context stops everything immediately.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// RetryPolicy - [retry]
+type RetryPolicy struct {
+	MaxAttempts      int     `json:"max_attempts"` // Per model, including the first try
+	InitialBackoffMs int     `json:"initial_backoff_ms"`
+	MaxBackoffMs     int     `json:"max_backoff_ms"`
+	Multiplier       float64 `json:"multiplier"`
+	Jitter           float64 `json:"jitter"` // 0 = exact backoff, 0.5 = ±50%
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      3,
+		InitialBackoffMs: 1000,
+		MaxBackoffMs:     15000,
+		Multiplier:       2.0,
+		Jitter:           0.2,
+	}
+}
+
+// backoff - Delay before retry number `attempt` (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := float64(p.InitialBackoffMs) * math.Pow(p.Multiplier, float64(attempt-1))
+	base = math.Min(base, float64(p.MaxBackoffMs))
+	jittered := base * (1 + p.Jitter*(2*rand.Float64()-1))
+	return time.Duration(jittered) * time.Millisecond
+}
+
+// retryClassifier - Errors that already know whether they're transient (replayed cassette errors)
+type retryClassifier interface {
+	Retryable() bool
+}
+
+// isRetryableError - Transient failures worth another attempt on the same model
+func isRetryableError(err error) bool {
+	var classified retryClassifier
+	if errors.As(err, &classified) {
+		return classified.Retryable()
+	}
+
+	var apiErr *openrouter.APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openrouter.RequestError
+	if errors.As(err, &reqErr) {
+		return isRetryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true // Timeouts, resets, refused connections
+	}
+	return false
+}
+
+// httpStatusOf - Status code carried by an API/request error, 0 when there is none
+func httpStatusOf(err error) int {
+	var apiErr *openrouter.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openrouter.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	var withStatus interface{ HTTPStatus() int }
+	if errors.As(err, &withStatus) {
+		return withStatus.HTTPStatus()
+	}
+	return 0
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= 500
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🔗 RETRYING BACKEND
+// ═══════════════════════════════════════════════════════════════════════════
+
+// RetryingBackend - Wraps a backend with the retry policy and a stage's fallback chain
+type RetryingBackend struct {
+	inner     LLMBackend
+	policy    RetryPolicy
+	fallbacks []StageRouting
+
+	mu        sync.Mutex
+	lastRoute *StageRouting // The link that answered the most recent successful call
+}
+
+// NewRetryingBackend wraps inner; nil stays nil (offline mode)
+func NewRetryingBackend(inner LLMBackend, policy RetryPolicy, fallbacks []StageRouting) LLMBackend {
+	if inner == nil {
+		return nil
+	}
+	return &RetryingBackend{inner: inner, policy: policy, fallbacks: fallbacks}
+}
+
+func (b *RetryingBackend) Name() string {
+	return b.inner.Name()
+}
+
+func (b *RetryingBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	chain := append([]StageRouting{{Model: req.Model, Providers: req.Providers}}, b.fallbacks...)
+
+	var lastErr error
+	for i, route := range chain {
+		attemptReq := req
+		attemptReq.Model = route.Model
+		attemptReq.Providers = route.Providers
+		if i > 0 {
+			log.Printf("↪️  Falling back to %s %v after: %v", route.Model, route.Providers, lastErr)
+		}
+
+		resp, err := b.tryRoute(ctx, attemptReq)
+		if err == nil {
+			answered := StageRouting{Model: route.Model, Providers: route.Providers, Fallback: i > 0}
+			b.mu.Lock()
+			b.lastRoute = &answered
+			b.mu.Unlock()
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return ChatResponse{}, err
+		}
+		lastErr = err
+	}
+
+	if len(chain) > 1 {
+		return ChatResponse{}, fmt.Errorf("all %d models failed, last error: %w", len(chain), lastErr)
+	}
+	return ChatResponse{}, lastErr
+}
+
+// answeredRoute - The route that produced the last successful answer; ok is false before any success
+func answeredRoute(backend LLMBackend) (route StageRouting, ok bool) {
+	b, isRetrying := backend.(*RetryingBackend)
+	if !isRetrying {
+		return StageRouting{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.lastRoute == nil {
+		return StageRouting{}, false
+	}
+	return *b.lastRoute, true
+}
+
+// tryRoute - Up to MaxAttempts on one model; fatal errors return immediately
+func (b *RetryingBackend) tryRoute(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	var err error
+	for attempt := 1; attempt <= max(1, b.policy.MaxAttempts); attempt++ {
+		var resp ChatResponse
+		resp, err = b.inner.ChatWithTools(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || !isRetryableError(err) || attempt == b.policy.MaxAttempts {
+			return ChatResponse{}, err
+		}
+
+		delay := b.policy.backoff(attempt)
+		log.Printf("🔁 %s attempt %d/%d failed, retrying in %s: %v", req.Model, attempt, b.policy.MaxAttempts, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ChatResponse{}, err
+		}
+	}
+	return ChatResponse{}, err
+}
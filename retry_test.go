@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestSearchTermStageReportsAnsweringFallback(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.SearchTerms.Fallbacks = []StageRouting{{Model: KIMI_K2_THINKING.Name, Providers: []string{"google-vertex"}}}
+
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{err: &openrouter.APIError{Message: "model unavailable", HTTPStatusCode: http.StatusNotFound}},
+		{terms: cassetteRefinedTerms},
+	}}
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Routing == nil {
+		t.Fatal("routing not recorded")
+	}
+	if result.Routing.Model != KIMI_K2_THINKING.Name || !result.Routing.Fallback {
+		t.Errorf("routing = %+v, want the kimi fallback", *result.Routing)
+	}
+}
+
+func TestAnsweredRouteBeforeAnySuccess(t *testing.T) {
+	backend := NewRetryingBackend(&scriptedBackend{}, defaultRetryPolicy(), nil)
+	if _, ok := answeredRoute(backend); ok {
+		t.Error("answeredRoute reported a route before any call succeeded")
+	}
+	if _, ok := answeredRoute(&scriptedBackend{}); ok {
+		t.Error("answeredRoute reported a route for a non-retrying backend")
+	}
+}
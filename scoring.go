@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧮 QUALITY SCORING - One 0–100 Number per Search Term Set
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Every positive signal is a 0–1 value multiplied by its weight:
+//   pattern coverage, word and semantic diversity, length mix, theme relevance.
+// Pattern coverage is itself weighted per rule (PatternRule.Weight).
+// The weighted average is scaled to 100, then penalties come off:
+//   per duplicate term, per term away from the target count.
+//
+// Refinement stops when the best set reaches target_score (and the
+// min_pattern_coverage/min_diversity floors), or when MAX_STALLED_REFINEMENTS
+// refinements in a row improve the best score by less than min_improvement.
+//
+// All weights live in [search_terms.scoring].
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	DEFAULT_TARGET_SCORE    = 75.0
+	DEFAULT_MIN_IMPROVEMENT = 2.0 // Score points a refinement must add to be worth another call
+
+	LONG_TAIL_MIN_WORDS   = 4   // "best romance audiobooks for commute" is long-tail, "romance audiobooks" is not
+	IDEAL_LONG_TAIL_SHARE = 0.6 // Mostly long-tail, with some short head terms
+)
+
+// ScoringWeights - [search_terms.scoring]
+type ScoringWeights struct {
+	Patterns          float64 `json:"patterns"`           // Split between rules by their own weight
+	Diversity         float64 `json:"diversity"`          // Unique-word ratio
+	SemanticDiversity float64 `json:"semantic_diversity"` // Embedding-based (embeddings.go)
+	LengthMix         float64 `json:"length_mix"`
+	ThemeRelevance    float64 `json:"theme_relevance"`
+
+	DuplicatePenalty float64 `json:"duplicate_penalty"` // Points off per duplicate term
+	CountPenalty     float64 `json:"count_penalty"`     // Points off per term above/below the target count
+
+	TargetScore    float64 `json:"target_score"`    // Stop refining at or above this
+	MinImprovement float64 `json:"min_improvement"` // Stop when refinements keep gaining less than this
+}
+
+func defaultScoringWeights() ScoringWeights {
+	return ScoringWeights{
+		Patterns:          5.5, // The six built-in rules' weights
+		Diversity:         1,
+		SemanticDiversity: 1,
+		LengthMix:         1,
+		ThemeRelevance:    1.5,
+		DuplicatePenalty:  5,
+		CountPenalty:      5,
+		TargetScore:       DEFAULT_TARGET_SCORE,
+		MinImprovement:    DEFAULT_MIN_IMPROVEMENT,
+	}
+}
+
+// positiveWeight - Sum of the signal weights (the 100-point denominator)
+func (w ScoringWeights) positiveWeight() float64 {
+	return w.Patterns + w.Diversity + w.SemanticDiversity + w.LengthMix + w.ThemeRelevance
+}
+
+// Score - Weighted 0–100 score for a quality snapshot
+func (w ScoringWeights) Score(quality SearchTermQuality, targetCount int) float64 {
+	total := w.positiveWeight()
+	if total <= 0 {
+		return 0
+	}
+
+	signals := w.Patterns*quality.PatternCoverage +
+		w.Diversity*quality.DiversityScore +
+		w.SemanticDiversity*quality.SemanticDiversity +
+		w.LengthMix*lengthMixSignal(quality.LongTailShare) +
+		w.ThemeRelevance*quality.ThemeRelevance
+
+	score := 100 * signals / total
+	score -= w.DuplicatePenalty * float64(quality.Duplicates)
+	score -= w.CountPenalty * math.Abs(float64(quality.TermCount-targetCount))
+	return math.Round(math.Max(0, math.Min(100, score))*10) / 10
+}
+
+// lengthMixSignal - 1 at IDEAL_LONG_TAIL_SHARE, falling linearly to 0 at all-short or all-long
+func lengthMixSignal(longTailShare float64) float64 {
+	distance := math.Abs(longTailShare - IDEAL_LONG_TAIL_SHARE)
+	return math.Max(0, 1-distance/math.Max(IDEAL_LONG_TAIL_SHARE, 1-IDEAL_LONG_TAIL_SHARE))
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📏 METRICS - Inputs for the score (all local, all cheap)
+// ═══════════════════════════════════════════════════════════════════════════
+
+// longTailShare - Fraction of terms with at least LONG_TAIL_MIN_WORDS words
+func longTailShare(terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	long := 0
+	for _, term := range terms {
+		if len(strings.Fields(term)) >= LONG_TAIL_MIN_WORDS {
+			long++
+		}
+	}
+	return float64(long) / float64(len(terms))
+}
+
+// themeRelevance - Fraction of terms sharing at least one content word with the theme
+func themeRelevance(terms []string, theme string) float64 {
+	themeWords := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(theme)) {
+		if !THEME_STOP_WORDS[word] {
+			themeWords[singularize(word)] = true
+		}
+	}
+	if len(terms) == 0 {
+		return 0
+	}
+	if len(themeWords) == 0 {
+		return 1 // Theme is all generic words ("audiobooks"): nothing to be off-topic from
+	}
+
+	relevant := 0
+	for _, term := range terms {
+		for _, word := range strings.Fields(strings.ToLower(term)) {
+			if themeWords[singularize(word)] {
+				relevant++
+				break
+			}
+		}
+	}
+	return float64(relevant) / float64(len(terms))
+}
+
+// THEME_STOP_WORDS - Theme words too generic to count as "on theme"
+var THEME_STOP_WORDS = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "for": true,
+	"book": true, "books": true, "audiobook": true, "audiobooks": true, "ebook": true, "ebooks": true,
+
+	// Same for the other built-in locales
+	"och": true, "för": true, "bok": true, "böcker": true, "ljudbok": true, "ljudböcker": true, "e-bok": true, "e-böcker": true,
+	"ja": true, "kirja": true, "kirjat": true, "äänikirja": true, "äänikirjat": true, "e-kirja": true, "e-kirjat": true,
+	"und": true, "für": true, "buch": true, "bücher": true, "hörbuch": true, "hörbücher": true, "e-book": true, "e-books": true,
+	"en": true, "voor": true, "boek": true, "boeken": true, "luisterboek": true, "luisterboeken": true,
+}
+
+// singularize - Crude English plural folding, good enough for word overlap
+func singularize(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestScoreRewardsPatternsAndPenalisesDuplicates(t *testing.T) {
+	weights := defaultScoringWeights()
+	score := func(terms []string) float64 {
+		return weights.Score(evaluateSearchTermQuality(terms, "romance books", defaultConfig().SearchTerms), len(cassetteRefinedTerms))
+	}
+
+	initial, refined := score(cassetteInitialTerms), score(cassetteRefinedTerms)
+	if refined <= initial {
+		t.Errorf("refined %.1f <= initial %.1f, want pattern coverage to raise the score", refined, initial)
+	}
+	if refined < weights.TargetScore || initial >= weights.TargetScore {
+		t.Errorf("initial %.1f, refined %.1f: want only the refined set at or above target %.1f", initial, refined, weights.TargetScore)
+	}
+
+	duplicated := append([]string(nil), cassetteRefinedTerms...)
+	duplicated[14] = "Best  Romance Audiobooks"
+	if q := evaluateSearchTermQuality(duplicated, "romance books", defaultConfig().SearchTerms); q.Duplicates != 1 {
+		t.Errorf("duplicates = %d, want 1 (case and spacing ignored)", q.Duplicates)
+	}
+	if got := score(duplicated); got >= refined {
+		t.Errorf("one duplicate scored %.1f, want below %.1f", got, refined)
+	}
+
+	offTheme := []string{"best cookbooks", "top cookbooks 2025", "cookbooks vs meal kits"}
+	if q := evaluateSearchTermQuality(offTheme, "romance books", defaultConfig().SearchTerms); q.ThemeRelevance != 0 {
+		t.Errorf("theme relevance = %.2f for off-theme terms, want 0", q.ThemeRelevance)
+	}
+}
+
+func TestGenerateStopsOnDiminishingReturns(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.SearchTerms.Scoring.TargetScore = 100 // Unreachable: only the improvement rule can stop early
+
+	// Refinements 2 and 3 change nothing, so the run stops before a fourth refinement
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: cassetteInitialTerms},
+		{terms: cassetteRefinedTerms},
+		{terms: cassetteRefinedTerms},
+		{terms: cassetteRefinedTerms},
+		{terms: cassetteRefinedTerms},
+	}}
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != SEARCH_TERM_STATUS_NO_IMPROVEMENT {
+		t.Errorf("status = %q, want %q", result.Status, SEARCH_TERM_STATUS_NO_IMPROVEMENT)
+	}
+	if len(result.Trace) != 4 || len(backend.steps) != 1 {
+		t.Errorf("trace has %d candidates with %d steps unused, want 4 and 1", len(result.Trace), len(backend.steps))
+	}
+	if result.BestIteration != 1 {
+		t.Errorf("best iteration = %d, want 1 (ties keep the earlier set)", result.BestIteration)
+	}
+}
+
+func TestOneWorseRefinementDoesNotStopTheLoop(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: cassetteInitialTerms},
+		{terms: cassetteInitialTerms[:10]},
+		{err: fmt.Errorf("provider hiccup")},
+	}}
+	cfg.Retry.MaxAttempts = 1
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The worse set is one miss; the loop goes on, and the failed call is refinement 2, not 1 again
+	if len(result.Trace) != 3 || result.Status != SEARCH_TERM_STATUS_REFINEMENT_FAILED {
+		t.Fatalf("status %q, trace = %+v", result.Status, result.Trace)
+	}
+	if failed := result.Trace[2]; failed.Iteration != 2 || failed.Stage != "search_terms.refinement_2" || failed.Error == "" {
+		t.Errorf("failed candidate = %+v, want iteration 2", failed)
+	}
+}
+
+func TestValidateRejectsBadScoring(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.SearchTerms.Scoring.Diversity = -1
+	cfg.SearchTerms.Scoring.TargetScore = 120
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("negative weight and target_score 120 passed validation")
+	}
+}
@@ -7,13 +7,15 @@ import (
 )
 
 var (
-	SEARCH_TERMS_MODEL     = MINIMAX_M2.Name()
-	SEARCH_TERMS_PROVIDERS = []string{MINIMAX_M2["Google"]}
+	SEARCH_TERMS_MODEL     = MINIMAX_M2.Name
+	SEARCH_TERMS_PROVIDERS = []string{MINIMAX_M2.mustProvider("Google")}
 )
 
 // ═══════════════════════════════════════════════════════════════════════════
 // 🔍 SEARCH TERM PROMPTS - Centralized Prompt Management
 // ═══════════════════════════════════════════════════════════════════════════
+//
+// These are the defaults; [search_terms.prompts] in the config file replaces them.
 
 const (
 	// Initial generation system prompt
@@ -61,20 +63,30 @@ Generate EXACTLY %d improved search terms that:
 2. Add new terms covering missing patterns
 3. Ensure high diversity and conversion focus
 
+Use the submit_search_terms tool with EXACTLY %d terms.`
+
+	// Top-up user prompt template (a response came back short; only the gap is requested)
+	TOP_UP_USER_PROMPT_TEMPLATE = `
+Add EXACTLY %d NEW search terms for theme "%s".
+
+We already have these - do NOT repeat or rephrase any of them:
+%s
+
+Cover angles the list above is missing.
 Use the submit_search_terms tool with EXACTLY %d terms.`
 )
 
 // generateSearchTerms - Simple wrapper around the specialized SearchTermAgent
-func generateSearchTerms(ctx context.Context, apiKey, theme string, keywords []string) ([]string, error) {
+func generateSearchTerms(ctx context.Context, backend LLMBackend, theme string, keywords []string, cfg SearchTermStageConfig, budget *Budget) (SearchTermResult, error) {
 	// Create the specialist agent
-	agent := NewSearchTermAgent(theme, keywords, apiKey, SEARCH_TERMS_MODEL, SEARCH_TERMS_PROVIDERS)
+	agent := NewSearchTermAgent(theme, keywords, backend, cfg, budget)
 
 	// Let it do its magic!
 	terms, err := agent.Generate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("search term agent failed: %w", err)
+		return SearchTermResult{}, fmt.Errorf("search term agent failed: %w", err)
 	}
 
 	log.Printf("✨ Search Term Specialist completed with %d terms", len(terms))
-	return terms, nil
+	return agent.Result(), nil
 }
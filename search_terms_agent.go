@@ -3,11 +3,10 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
-
-	openrouter "github.com/revrost/go-openrouter"
 )
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -24,9 +23,24 @@ import (
 //
 // ═══════════════════════════════════════════════════════════════════════════
 
+// Defaults - overridable per run via [search_terms] in the config file
+const (
+	MAX_REFINEMENT_ITERATIONS = 4   // Total: 1 initial + 4 refinements = 5 calls max
+	TARGET_SEARCH_TERM_COUNT  = 15  // We want exactly 15 search terms
+	MIN_PATTERN_COVERAGE      = 4   // Out of the enabled pattern rules (6 built in)
+	MIN_DIVERSITY_SCORE       = 0.6 // Unique words / total words
+	MAX_STALLED_REFINEMENTS   = 2   // Refinements in a row gaining < min_improvement before giving up
+)
+
+// Why Generate stopped refining (SearchTermResult.Status)
 const (
-	MAX_REFINEMENT_ITERATIONS = 4  // Total: 1 initial + 4 refinements = 5 calls max
-	TARGET_SEARCH_TERM_COUNT  = 15 // We want exactly 15 search terms
+	SEARCH_TERM_STATUS_TARGET_REACHED    = "target_reached"
+	SEARCH_TERM_STATUS_NO_IMPROVEMENT    = "diminishing_returns" // MAX_STALLED_REFINEMENTS in a row gained < min_improvement
+	SEARCH_TERM_STATUS_MAX_ITERATIONS    = "max_iterations"
+	SEARCH_TERM_STATUS_REFINEMENT_FAILED = "refinement_failed"
+	SEARCH_TERM_STATUS_BUDGET_EXHAUSTED  = "budget_exhausted"
+	SEARCH_TERM_STATUS_TIMED_OUT         = "timed_out" // A refinement call or the stage deadline ran out
+	SEARCH_TERM_STATUS_OFFLINE           = "offline"   // Fallback templates, no agent
 )
 
 // SearchTermAgent - The obsessed search term craftsman
@@ -35,31 +49,76 @@ type SearchTermAgent struct {
 	theme        string
 	baseKeywords []string
 
-	// API config
-	apiKey    string
-	modelName string
-	providers []string
-
-	// Current state
-	currentTerms []string
-	iteration    int
+	// API config + targets/thresholds/prompts
+	backend  LLMBackend
+	cfg      SearchTermStageConfig
+	budget   *Budget  // nil = only MaxRefinementIterations limits the loop
+	embedder Embedder // nil = word-ratio diversity only
+
+	// Current state (currentTerms is always the best-scoring candidate so far)
+	currentTerms  []string
+	history       []SearchTermCandidate
+	best          int                          // Index into history
+	stalled       int                          // Refinements in a row that gained < min_improvement (a worse set counts too)
+	repairs       []string                     // What collectTerms had to fix in the latest response (repair.go)
+	fields        map[string]map[string]string // The latest response's per-term annotations (term_records.go)
+	iteration     int
+	apiCalls      int
+	status        string
+	stopReason    string
+	timedOutStage string
 }
 
-// SearchTermQuality - HARDCODED quality metrics for search terms
+// SearchTermQuality - Quality metrics for search terms
 type SearchTermQuality struct {
-	// SEO Pattern Coverage (hardcoded search term knowledge!)
-	HasComparisons bool // e.g., "X vs Y", "X alternative"
-	HasQuestions   bool // e.g., "where to find X", "how to get X"
-	HasBestLists   bool // e.g., "best X for Y", "top X in 2025"
-	HasValueTerms  bool // e.g., "unlimited X", "free X trial"
-	HasFormatMix   bool // e.g., "X audiobooks", "X ebooks"
-	HasUserIntent  bool // e.g., "X for beginners", "X for commute"
+	// SEO Pattern Coverage (rules from patterns.go / [[search_terms.patterns]])
+	Patterns        map[string]bool `json:"patterns"`         // Rule id → covered by some term
+	PatternCoverage float64         `json:"pattern_coverage"` // Covered share of the rule weights
 
 	// Diversity
-	DiversityScore float64 // How unique are the terms?
+	DiversityScore    float64         `json:"diversity_score"`           // How unique are the words? (unique / total)
+	SemanticDiversity float64         `json:"semantic_diversity"`        // 1 - mean pairwise cosine (embeddings.go)
+	NearDuplicates    []NearDuplicate `json:"near_duplicates,omitempty"` // Pairs at or above near_duplicate_threshold
+	Duplicates        int             `json:"duplicates"`                // Terms repeating an earlier one (dedupe.go)
+	DuplicateTerms    []TermDuplicate `json:"duplicate_terms,omitempty"`
+
+	// Shape and focus
+	LongTailShare  float64 `json:"long_tail_share"` // Terms with >= LONG_TAIL_MIN_WORDS words
+	ThemeRelevance float64 `json:"theme_relevance"` // Terms sharing a content word with the theme
 
 	// Coverage
-	TermCount int
+	TermCount int `json:"term_count"`
+}
+
+// SearchTermCandidate - One term set the agent saw (initial or refinement), for the trace
+type SearchTermCandidate struct {
+	Iteration int               `json:"iteration"` // 0 = initial generation
+	Stage     string            `json:"stage"`     // Usage label of the call that produced it
+	Terms     []string          `json:"terms,omitempty"`
+	Quality   SearchTermQuality `json:"quality"`
+	Score     float64           `json:"score"`
+	Error     string            `json:"error,omitempty"`   // Failed call: no terms, never selected
+	Repairs   []string          `json:"repairs,omitempty"` // REPAIR_* notes (repair.go)
+
+	fields map[string]map[string]string // Model annotations by term, for the records
+}
+
+// SearchTermResult - What a Generate run produced, beyond the terms themselves
+type SearchTermResult struct {
+	Terms      []string           `json:"search_terms"`
+	Records    []SearchTermRecord `json:"records"` // One per term: intent, funnel stage, patterns, format, source keyword
+	Quality    SearchTermQuality  `json:"quality"`
+	Iterations int                `json:"iterations"` // Refinement calls that returned terms
+	APICalls   int                `json:"api_calls"`
+	Status     string             `json:"status"`                // SEARCH_TERM_STATUS_*
+	StopReason string             `json:"stop_reason,omitempty"` // e.g. which budget cap was hit
+
+	TimedOutStage string        `json:"timed_out_stage,omitempty"` // Usage label of the call (or "search_terms") that ran out of time
+	Routing       *StageRouting `json:"routing,omitempty"`         // Route of the last answered call (set by the stage runner)
+
+	Score         float64               `json:"score"`          // Of the returned (best) set
+	BestIteration int                   `json:"best_iteration"` // Which candidate won (0 = initial)
+	Trace         []SearchTermCandidate `json:"trace"`          // Every candidate, in call order
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -67,13 +126,18 @@ type SearchTermQuality struct {
 // ═══════════════════════════════════════════════════════════════════════════
 
 // NewSearchTermAgent creates a new specialized search term generator
-func NewSearchTermAgent(theme string, baseKeywords []string, apiKey string, modelName string, providers []string) *SearchTermAgent {
+func NewSearchTermAgent(theme string, baseKeywords []string, backend LLMBackend, cfg SearchTermStageConfig, budget *Budget) *SearchTermAgent {
+	embedder, err := cfg.Embeddings.New()
+	if err != nil {
+		log.Printf("⚠️  %v, using word-ratio diversity only", err)
+	}
 	return &SearchTermAgent{
 		theme:        theme,
 		baseKeywords: baseKeywords,
-		apiKey:       apiKey,
-		modelName:    modelName,
-		providers:    providers,
+		backend:      backend,
+		cfg:          cfg,
+		budget:       budget,
+		embedder:     embedder,
 		iteration:    0,
 	}
 }
@@ -82,39 +146,149 @@ func NewSearchTermAgent(theme string, baseKeywords []string, apiKey string, mode
 func (a *SearchTermAgent) Generate(ctx context.Context) ([]string, error) {
 	log.Printf("🔍 Search Term Specialist started for theme: %s", a.theme)
 
+	// No terms yet, so an exhausted budget here is a real failure
+	if err := a.budget.CheckNextCall(); err != nil {
+		return nil, err
+	}
+
 	// CALL 1: Generate initial search terms
 	terms, err := a.generateInitialTerms(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("initial generation failed: %w", err)
+		return nil, fmt.Errorf("initial generation failed: %w", asStageTimeout(USAGE_STAGE_INITIAL, err))
 	}
-	a.currentTerms = terms
-	log.Printf("✨ Generated %d initial terms", len(terms))
+	a.apiCalls++
+	a.record(ctx, 0, USAGE_STAGE_INITIAL, terms, nil)
+	log.Printf("✨ Generated %d initial terms (score %.1f)", len(terms), a.history[a.best].Score)
 
 	// CALLS 2-10: Refinement iterations (up to 9 more calls)
-	for a.iteration < MAX_REFINEMENT_ITERATIONS {
-		// Evaluate quality locally (NO API call here!)
-		quality := a.evaluateSearchTermQuality()
+	a.status = SEARCH_TERM_STATUS_MAX_ITERATIONS
+	for a.iteration < a.cfg.MaxRefinementIterations {
+		// Quality of the best set so far (evaluated locally in record, NO API call here!)
+		quality := a.history[a.best].Quality
 
 		// Check if we're good enough
-		if a.isGoodEnough(quality) {
+		if a.targetReached(a.history[a.best]) {
 			log.Printf("✅ Quality target reached after %d total calls", a.iteration+1)
+			a.status = SEARCH_TERM_STATUS_TARGET_REACHED
+			break
+		}
+
+		// Refinements keep missing: one bad draw is noise, several in a row are the ceiling
+		if a.stalled >= MAX_STALLED_REFINEMENTS {
+			log.Printf("📉 %d refinements in a row gained < %.1f points, stopping", a.stalled, a.cfg.Scoring.MinImprovement)
+			a.status = SEARCH_TERM_STATUS_NO_IMPROVEMENT
+			break
+		}
+
+		// Stage deadline passed: no point starting a call that can't finish
+		if ctx.Err() != nil {
+			log.Printf("⏱️  Search term stage out of time, keeping current terms")
+			a.status = SEARCH_TERM_STATUS_TIMED_OUT
+			a.stopReason = ctx.Err().Error()
+			a.timedOutStage = USAGE_STAGE_SEARCH_TERMS
+			break
+		}
+
+		// Out of money/tokens/time: keep what we have instead of failing
+		if err := a.budget.CheckNextCall(); err != nil {
+			log.Printf("💸 Stopping refinement, %v", err)
+			a.status = SEARCH_TERM_STATUS_BUDGET_EXHAUSTED
+			a.stopReason = err.Error()
 			break
 		}
 
 		// Refine the terms (1 API call per iteration)
 		log.Printf("🔄 Refinement iteration %d: improving coverage...", a.iteration+1)
+		stage := fmt.Sprintf(USAGE_STAGE_REFINEMENT_PATTERN, a.iteration+1)
 		refined, err := a.refineTermsIteration(ctx, quality)
+		a.apiCalls++
 		if err != nil {
+			a.record(ctx, a.iteration+1, stage, nil, err)
 			log.Printf("⚠️  Refinement %d failed, keeping current terms: %v", a.iteration+1, err)
+			a.status = SEARCH_TERM_STATUS_REFINEMENT_FAILED
+			if errors.Is(err, context.DeadlineExceeded) {
+				a.status = SEARCH_TERM_STATUS_TIMED_OUT
+				a.timedOutStage = stage
+				if ctx.Err() != nil {
+					a.timedOutStage = USAGE_STAGE_SEARCH_TERMS // The whole stage, not just this call
+				}
+			}
+			a.stopReason = err.Error()
 			break // Don't fail completely, just stop refining
 		}
 
-		a.currentTerms = refined
 		a.iteration++
+		if !a.record(ctx, a.iteration, stage, refined, nil) {
+			log.Printf("📉 Refinement %d scored %.1f, keeping best (%.1f from iteration %d)",
+				a.iteration, a.history[len(a.history)-1].Score, a.history[a.best].Score, a.history[a.best].Iteration)
+		}
+	}
+
+	terms = a.finalTerms()
+	if dropped := len(a.currentTerms) - len(terms); dropped > 0 {
+		log.Printf("🧹 Dropped %d duplicate terms from the final set", dropped)
+	}
+	log.Printf("🎉 Final: %d terms (score %.1f, iteration %d) after %d total API calls",
+		len(terms), a.history[a.best].Score, a.history[a.best].Iteration, a.apiCalls)
+	return terms, nil
+}
+
+// finalTerms - The best set without duplicates (later copies dropped)
+func (a *SearchTermAgent) finalTerms() []string {
+	terms, _ := dedupeTerms(a.currentTerms, a.cfg.DuplicateSimilarity, a.cfg.stopWordSet())
+	return terms
+}
+
+// record - Adds a candidate to the trace; returns true when it became the new best.
+// Ties keep the earlier set: a refinement has to actually improve things to win.
+func (a *SearchTermAgent) record(ctx context.Context, iteration int, stage string, terms []string, err error) bool {
+	candidate := SearchTermCandidate{Iteration: iteration, Stage: stage, Terms: terms, Repairs: a.repairs, fields: a.fields}
+	a.repairs, a.fields = nil, nil
+	if err != nil {
+		candidate.Error = err.Error()
+		a.history = append(a.history, candidate)
+		return false
 	}
 
-	log.Printf("🎉 Final: %d terms after %d total API calls", len(a.currentTerms), a.iteration+1)
-	return a.currentTerms, nil
+	candidate.Quality = assessSearchTerms(ctx, terms, a.theme, a.cfg, a.embedder)
+	candidate.Score = a.cfg.Scoring.Score(candidate.Quality, a.cfg.Count)
+	a.history = append(a.history, candidate)
+
+	if a.currentTerms != nil {
+		if candidate.Score-a.history[a.best].Score < a.cfg.Scoring.MinImprovement {
+			a.stalled++
+		} else {
+			a.stalled = 0
+		}
+		if candidate.Score <= a.history[a.best].Score {
+			return false
+		}
+	}
+	a.best = len(a.history) - 1
+	a.currentTerms = terms
+	return true
+}
+
+// Result - Final terms with their quality and call accounting (call after Generate)
+func (a *SearchTermAgent) Result() SearchTermResult {
+	terms := a.finalTerms()
+	result := SearchTermResult{
+		Terms:      terms,
+		Records:    buildTermRecords(terms, a.history[a.best].fields, a.baseKeywords, a.cfg.patternSet(), a.cfg.stopWordSet()),
+		Iterations: a.iteration,
+		APICalls:   a.apiCalls,
+		Status:     a.status,
+		StopReason: a.stopReason,
+
+		TimedOutStage: a.timedOutStage,
+		Trace:         a.history,
+	}
+	if len(a.history) > 0 {
+		result.Quality = a.history[a.best].Quality
+		result.Score = a.history[a.best].Score
+		result.BestIteration = a.history[a.best].Iteration
+	}
+	return result
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -122,45 +296,28 @@ func (a *SearchTermAgent) Generate(ctx context.Context) ([]string, error) {
 // ═══════════════════════════════════════════════════════════════════════════
 
 func (a *SearchTermAgent) generateInitialTerms(ctx context.Context) ([]string, error) {
-	client := openrouter.NewClient(
-		a.apiKey,
-		openrouter.WithHTTPReferer("https://github.com/booktok-hype-hub"),
-		openrouter.WithXTitle("Search Term Specialist"),
-	)
-
 	keywordList := strings.Join(a.baseKeywords, ", ")
 
-	// Use centralized prompts from search_terms.go
-	systemPrompt := INITIAL_GENERATION_SYSTEM_PROMPT
-
-	userPrompt := fmt.Sprintf(INITIAL_GENERATION_USER_PROMPT_TEMPLATE,
-		TARGET_SEARCH_TERM_COUNT, a.theme, keywordList, TARGET_SEARCH_TERM_COUNT)
-
-	resp, err := client.CreateChatCompletion(ctx, openrouter.ChatCompletionRequest{
-		Model: a.modelName,
-		Messages: []openrouter.ChatCompletionMessage{
-			{
-				Role:    openrouter.ChatMessageRoleSystem,
-				Content: openrouter.Content{Text: systemPrompt},
-			},
-			{
-				Role:    openrouter.ChatMessageRoleUser,
-				Content: openrouter.Content{Text: userPrompt},
-			},
-		},
-		Tools:       a.getSearchTermTool(),
-		Temperature: 0.8, // Creative but focused
-		Provider: &openrouter.ChatProvider{
-			Order:          a.providers,
-			AllowFallbacks: boolPtr(false),
-		},
+	// Centralized prompts (defaults in search_terms.go, overridable via config)
+	systemPrompt := a.cfg.Prompts.InitialSystem
+
+	userPrompt := fmt.Sprintf(a.cfg.Prompts.InitialUserTemplate,
+		a.cfg.Count, a.theme, keywordList, a.cfg.Count)
+
+	resp, err := a.backend.ChatWithTools(withUsageStage(ctx, USAGE_STAGE_INITIAL), ChatRequest{
+		Model:        a.cfg.Model,
+		Providers:    a.cfg.Providers,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		Tools:        a.getSearchTermTool(a.cfg.Count),
+		Temperature:  a.cfg.Spec.Temperature(a.cfg.InitialTemperature),
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return a.extractSearchTerms(resp)
+	return a.collectTerms(ctx, USAGE_STAGE_INITIAL, resp)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -168,113 +325,84 @@ func (a *SearchTermAgent) generateInitialTerms(ctx context.Context) ([]string, e
 // ═══════════════════════════════════════════════════════════════════════════
 
 func (a *SearchTermAgent) refineTermsIteration(ctx context.Context, quality SearchTermQuality) ([]string, error) {
-	client := openrouter.NewClient(
-		a.apiKey,
-		openrouter.WithHTTPReferer("https://github.com/booktok-hype-hub"),
-		openrouter.WithXTitle("Search Term Specialist"),
-	)
-
 	// Build FOCUSED refinement prompt - NO MESSAGE HISTORY!
 	// Just current terms + what's missing = STATELESS!
 	missingPatterns := a.identifyMissingPatterns(quality)
 
-	systemPrompt := REFINEMENT_SYSTEM_PROMPT
+	systemPrompt := a.cfg.Prompts.RefinementSystem
 
-	userPrompt := fmt.Sprintf(REFINEMENT_USER_PROMPT_TEMPLATE,
+	userPrompt := fmt.Sprintf(a.cfg.Prompts.RefinementUserTemplate,
 		len(a.currentTerms),
 		a.theme,
 		a.formatTermsForPrompt(a.currentTerms),
 		missingPatterns,
-		TARGET_SEARCH_TERM_COUNT,
-		TARGET_SEARCH_TERM_COUNT)
-
-	resp, err := client.CreateChatCompletion(ctx, openrouter.ChatCompletionRequest{
-		Model: a.modelName,
-		Messages: []openrouter.ChatCompletionMessage{
-			{
-				Role:    openrouter.ChatMessageRoleSystem,
-				Content: openrouter.Content{Text: systemPrompt},
-			},
-			{
-				Role:    openrouter.ChatMessageRoleUser,
-				Content: openrouter.Content{Text: userPrompt},
-			},
-		},
-		Tools:       a.getSearchTermTool(),
-		Temperature: 0.7, // Slightly more deterministic for refinement
-		Provider: &openrouter.ChatProvider{
-			Order:          a.providers,
-			AllowFallbacks: boolPtr(false),
-		},
+		a.cfg.Count,
+		a.cfg.Count)
+
+	stage := fmt.Sprintf(USAGE_STAGE_REFINEMENT_PATTERN, a.iteration+1)
+	resp, err := a.backend.ChatWithTools(withUsageStage(ctx, stage), ChatRequest{
+		Model:        a.cfg.Model,
+		Providers:    a.cfg.Providers,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		Tools:        a.getSearchTermTool(a.cfg.Count),
+		Temperature:  a.cfg.Spec.Temperature(a.cfg.RefinementTemperature),
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return a.extractSearchTerms(resp)
+	return a.collectTerms(ctx, stage, resp)
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
 // 📊 QUALITY EVALUATION - Local Logic (NO API CALLS!)
 // ═══════════════════════════════════════════════════════════════════════════
 
-// evaluateSearchTermQuality - HARDCODED search term pattern detection
-func (a *SearchTermAgent) evaluateSearchTermQuality() SearchTermQuality {
+// evaluateSearchTermQuality - Pattern detection by rule plus the scoring metrics
+func evaluateSearchTermQuality(terms []string, theme string, cfg SearchTermStageConfig) SearchTermQuality {
 	quality := SearchTermQuality{
-		TermCount: len(a.currentTerms),
+		TermCount: len(terms),
 	}
 
-	termsLower := make([]string, len(a.currentTerms))
-	for i, term := range a.currentTerms {
+	termsLower := make([]string, len(terms))
+	for i, term := range terms {
 		termsLower[i] = strings.ToLower(term)
 	}
 
-	// HARDCODED pattern detection - SEARCH TERM SPECIFIC!
-	for _, term := range termsLower {
-		// Comparison patterns
-		if strings.Contains(term, " vs ") || strings.Contains(term, " versus ") ||
-			strings.Contains(term, "alternative") || strings.Contains(term, "comparison") {
-			quality.HasComparisons = true
-		}
+	quality.Patterns, quality.PatternCoverage = cfg.patternSet().Detect(termsLower)
 
-		// Question patterns
-		if strings.HasPrefix(term, "where ") || strings.HasPrefix(term, "how ") ||
-			strings.HasPrefix(term, "what ") || strings.HasPrefix(term, "which ") {
-			quality.HasQuestions = true
-		}
-
-		// Best/Top lists
-		if strings.Contains(term, "best ") || strings.Contains(term, "top ") ||
-			strings.Contains(term, "most popular") {
-			quality.HasBestLists = true
-		}
-
-		// Value terms
-		if strings.Contains(term, "unlimited") || strings.Contains(term, "free") ||
-			strings.Contains(term, "trial") || strings.Contains(term, "affordable") {
-			quality.HasValueTerms = true
-		}
+	// Calculate diversity (simple: unique word count ratio)
+	quality.DiversityScore = calculateDiversity(termsLower)
+	quality.DuplicateTerms = findDuplicateTerms(terms, cfg.DuplicateSimilarity, cfg.stopWordSet())
+	quality.Duplicates = len(quality.DuplicateTerms)
+	quality.LongTailShare = longTailShare(termsLower)
+	quality.ThemeRelevance = themeRelevance(termsLower, theme)
 
-		// Format mix
-		if strings.Contains(term, "audiobook") || strings.Contains(term, "ebook") ||
-			strings.Contains(term, "book") || strings.Contains(term, "magazine") {
-			quality.HasFormatMix = true
-		}
+	return quality
+}
 
-		// User intent
-		if strings.Contains(term, " for ") {
-			quality.HasUserIntent = true
-		}
+// assessSearchTerms - evaluateSearchTermQuality plus semantic diversity from the embedder.
+// Without one (or when it fails) semantic diversity falls back to the word ratio.
+func assessSearchTerms(ctx context.Context, terms []string, theme string, cfg SearchTermStageConfig, embedder Embedder) SearchTermQuality {
+	quality := evaluateSearchTermQuality(terms, theme, cfg)
+	quality.SemanticDiversity = quality.DiversityScore
+	if embedder == nil {
+		return quality
 	}
 
-	// Calculate diversity (simple: unique word count ratio)
-	quality.DiversityScore = a.calculateDiversity(termsLower)
-
+	diversity, nearDuplicates, err := semanticDiversity(ctx, embedder, terms, cfg.Embeddings.NearDuplicateThreshold)
+	if err != nil {
+		log.Printf("⚠️  Embedding failed, using word-ratio diversity: %v", err)
+		return quality
+	}
+	quality.SemanticDiversity = diversity
+	quality.NearDuplicates = nearDuplicates
 	return quality
 }
 
-func (a *SearchTermAgent) calculateDiversity(terms []string) float64 {
+func calculateDiversity(terms []string) float64 {
 	wordSet := make(map[string]bool)
 	totalWords := 0
 
@@ -293,116 +421,177 @@ func (a *SearchTermAgent) calculateDiversity(terms []string) float64 {
 	return float64(len(wordSet)) / float64(totalWords)
 }
 
-// isGoodEnough - HARDCODED quality thresholds for search terms
-func (a *SearchTermAgent) isGoodEnough(quality SearchTermQuality) bool {
-	// Must have correct count
-	if quality.TermCount != TARGET_SEARCH_TERM_COUNT {
-		return false
-	}
-
-	// Must cover at least 4 out of 6 patterns
-	patternCount := 0
-	if quality.HasComparisons {
-		patternCount++
-	}
-	if quality.HasQuestions {
-		patternCount++
-	}
-	if quality.HasBestLists {
-		patternCount++
-	}
-	if quality.HasValueTerms {
-		patternCount++
-	}
-	if quality.HasFormatMix {
-		patternCount++
-	}
-	if quality.HasUserIntent {
-		patternCount++
+// countPatterns - How many pattern rules are covered
+func countPatterns(quality SearchTermQuality) int {
+	count := 0
+	for _, covered := range quality.Patterns {
+		if covered {
+			count++
+		}
 	}
+	return count
+}
 
-	// Must have good diversity
-	return patternCount >= 4 && quality.DiversityScore >= 0.6
+// targetReached - Score at or above [search_terms.scoring] target_score, with the
+// required rules and the min_pattern_coverage/min_diversity floors applying on top
+func (a *SearchTermAgent) targetReached(candidate SearchTermCandidate) bool {
+	return candidate.Score >= a.cfg.Scoring.TargetScore &&
+		len(a.cfg.patternSet().MissingRequired(candidate.Quality.Patterns)) == 0 &&
+		countPatterns(candidate.Quality) >= a.cfg.MinPatternCoverage &&
+		candidate.Quality.DiversityScore >= a.cfg.MinDiversity
 }
 
-// identifyMissingPatterns - HARDCODED search term pattern knowledge
+// identifyMissingPatterns - The "MISSING PATTERNS" section, built from the pattern rules,
+// followed by duplicates to replace and near-duplicate pairs the embedder flagged
 func (a *SearchTermAgent) identifyMissingPatterns(quality SearchTermQuality) string {
-	var missing []string
-
-	if !quality.HasComparisons {
-		missing = append(missing, "- Comparison terms (e.g., 'X vs Y', 'X alternative')")
-	}
-	if !quality.HasQuestions {
-		missing = append(missing, "- Question-based (e.g., 'where to find X', 'how to get X')")
-	}
-	if !quality.HasBestLists {
-		missing = append(missing, "- Best/Top lists (e.g., 'best X for Y', 'top X in 2025')")
-	}
-	if !quality.HasValueTerms {
-		missing = append(missing, "- Value-focused (e.g., 'unlimited X', 'free X trial')")
-	}
-	if !quality.HasFormatMix {
-		missing = append(missing, "- Format combinations (e.g., 'X audiobooks', 'X ebooks')")
-	}
-	if !quality.HasUserIntent {
-		missing = append(missing, "- User intent (e.g., 'X for beginners', 'X for commute')")
+	section := "None - improve diversity and specificity!"
+	if missing := a.cfg.patternSet().DescribeMissing(quality.Patterns); len(missing) > 0 {
+		section = strings.Join(missing, "\n")
 	}
 
-	if len(missing) == 0 {
-		return "None - improve diversity and specificity!"
+	replaced := make(map[string]bool)
+	if len(quality.DuplicateTerms) > 0 {
+		var lines []string
+		for _, d := range quality.DuplicateTerms {
+			lines = append(lines, fmt.Sprintf("- %q (same search as %q)", d.Term, d.DuplicateOf))
+			replaced[d.Term] = true
+		}
+		section += "\n\nDUPLICATES - replace these with new, distinct terms:\n" + strings.Join(lines, "\n")
 	}
 
-	return strings.Join(missing, "\n")
+	var pairs []string
+	for _, pair := range quality.NearDuplicates {
+		if !replaced[pair.A] && !replaced[pair.B] {
+			pairs = append(pairs, fmt.Sprintf("- %q ≈ %q", pair.A, pair.B))
+		}
+	}
+	if len(pairs) > 0 {
+		section += "\n\nNEAR-DUPLICATES (keep one of each pair, replace the other with a new angle):\n" + strings.Join(pairs, "\n")
+	}
+	return section
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
 // 🛠️ HELPERS - Search Term Specific Utilities
 // ═══════════════════════════════════════════════════════════════════════════
 
-func (a *SearchTermAgent) getSearchTermTool() []openrouter.Tool {
-	return []openrouter.Tool{
+func (a *SearchTermAgent) getSearchTermTool(count int) []ToolDefinition {
+	return []ToolDefinition{
 		{
-			Type: openrouter.ToolTypeFunction,
-			Function: &openrouter.FunctionDefinition{
-				Name:        "submit_search_terms",
-				Description: "Submit exactly 15 specific must-target search terms",
-				Parameters: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"search_terms": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "Array of exactly 15 specific search terms",
-							"minItems": 15,
-							"maxItems": 15
-						}
-					},
-					"required": ["search_terms"]
-				}`),
-			},
+			Name:        "submit_search_terms",
+			Description: fmt.Sprintf("Submit exactly %d specific must-target search terms", count),
+			Parameters: json.RawMessage(fmt.Sprintf(`{
+				"type": "object",
+				"properties": {
+					"search_terms": {
+						"type": "array",
+						"items": `+termRecordSchema()+`,
+						"description": "Array of exactly %d specific search terms, each tagged with intent, funnel stage, format and the base keyword it came from",
+						"minItems": %d,
+						"maxItems": %d
+					}
+				},
+				"required": ["search_terms"]
+			}`, count, count, count)),
 		},
 	}
 }
 
-func (a *SearchTermAgent) extractSearchTerms(resp openrouter.ChatCompletionResponse) ([]string, error) {
-	if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 {
-		return nil, fmt.Errorf("no tool call in response")
+// collectTerms - The terms of a response, repaired (repair.go) and brought to exactly Count:
+// too many → trimmed by score, too few → one top-up call for only the missing ones
+func (a *SearchTermAgent) collectTerms(ctx context.Context, stage string, resp ChatResponse) ([]string, error) {
+	list, err := repairToolList(resp, "search_terms")
+	if err != nil {
+		return nil, err
+	}
+	terms, repairs := list.Items, list.Repairs
+	fields := make(map[string]map[string]string)
+	annotate := func(list RepairedList) {
+		for i, term := range list.Items {
+			if list.Fields[i] != nil {
+				fields[term] = list.Fields[i]
+			}
+		}
+	}
+	annotate(list)
+	if len(repairs) > 0 {
+		log.Printf("🩹 Repaired %s response (%s)", stage, strings.Join(repairs, ", "))
+	}
+
+	if missing := a.cfg.Count - len(terms); missing > 0 {
+		extra, err := a.topUpTerms(ctx, stage, terms, missing)
+		if err != nil {
+			log.Printf("⚠️  Top-up for %d missing terms failed, keeping %d: %v", missing, len(terms), err)
+		} else {
+			log.Printf("➕ Topped up %d terms with %d more", len(terms), len(extra.Items))
+			terms = append(terms, extra.Items...)
+			annotate(extra)
+			repairs = append(repairs, REPAIR_TOPPED_UP)
+		}
 	}
 
-	var result struct {
-		SearchTerms []string `json:"search_terms"`
+	if len(terms) > a.cfg.Count {
+		log.Printf("✂️  Trimming %d terms to the best-scoring %d", len(terms), a.cfg.Count)
+		terms = a.trimByScore(ctx, terms, a.cfg.Count)
+		repairs = append(repairs, REPAIR_TRIMMED)
 	}
+	a.repairs, a.fields = repairs, fields
+	return terms, nil
+}
 
-	args := resp.Choices[0].Message.ToolCalls[0].Function.Arguments
-	if err := json.Unmarshal([]byte(args), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+// topUpTerms - One extra call asking only for the missing terms (counts against the budget)
+func (a *SearchTermAgent) topUpTerms(ctx context.Context, stage string, terms []string, missing int) (RepairedList, error) {
+	if err := a.budget.CheckNextCall(); err != nil {
+		return RepairedList{}, err
+	}
+	userPrompt := fmt.Sprintf(a.cfg.Prompts.TopUpUserTemplate,
+		missing, a.theme, a.formatTermsForPrompt(terms), missing)
+
+	resp, err := a.backend.ChatWithTools(withUsageStage(ctx, stage+USAGE_STAGE_TOP_UP_SUFFIX), ChatRequest{
+		Model:        a.cfg.Model,
+		Providers:    a.cfg.Providers,
+		SystemPrompt: a.cfg.Prompts.InitialSystem,
+		UserPrompt:   userPrompt,
+		Tools:        a.getSearchTermTool(missing),
+		Temperature:  a.cfg.Spec.Temperature(a.cfg.InitialTemperature),
+	})
+	a.apiCalls++
+	if err != nil {
+		return RepairedList{}, err
 	}
+	return repairToolList(resp, "search_terms")
+}
 
-	if len(result.SearchTerms) != TARGET_SEARCH_TERM_COUNT {
-		return nil, fmt.Errorf("expected %d terms, got %d", TARGET_SEARCH_TERM_COUNT, len(result.SearchTerms))
+// trimByScore - Later duplicate copies go first; then terms are dropped one at a time,
+// always the one whose removal scores best (on ties the later term goes), until count remain.
+// Each term is embedded once for the whole trim.
+func (a *SearchTermAgent) trimByScore(ctx context.Context, terms []string, count int) []string {
+	_, duplicates := dedupeTerms(terms, a.cfg.DuplicateSimilarity, a.cfg.stopWordSet())
+	drop := make(map[int]bool)
+	for _, d := range duplicates[:min(len(duplicates), len(terms)-count)] {
+		drop[d.index] = true
+	}
+	var kept []string
+	for i, term := range terms {
+		if !drop[i] {
+			kept = append(kept, term)
+		}
 	}
 
-	return result.SearchTerms, nil
+	terms = kept
+	embedder := NewCachedEmbedder(a.embedder)
+	for len(terms) > count {
+		drop, bestScore := 0, -1.0
+		for i := range terms {
+			candidate := append(append([]string(nil), terms[:i]...), terms[i+1:]...)
+			quality := assessSearchTerms(ctx, candidate, a.theme, a.cfg, embedder)
+			if score := a.cfg.Scoring.Score(quality, count); score >= bestScore {
+				drop, bestScore = i, score
+			}
+		}
+		terms = append(terms[:drop], terms[drop+1:]...)
+	}
+	return terms
 }
 
 func (a *SearchTermAgent) formatTermsForPrompt(terms []string) string {
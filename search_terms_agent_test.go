@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGenerateReturnsBestScoringCandidate(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	agent := NewSearchTermAgent("romance books", []string{"romance books"}, nil, cfg.SearchTerms, nil)
+
+	// Good initial set, a worse refinement (short and pattern-poor), then a failed call
+	worse := append([]string(nil), cassetteInitialTerms[:10]...)
+	agent.record(context.Background(), 0, USAGE_STAGE_INITIAL, cassetteRefinedTerms, nil)
+	agent.iteration++
+	if agent.record(context.Background(), 1, "search_terms.refinement_1", worse, nil) {
+		t.Fatal("a lower-scoring refinement replaced the best set")
+	}
+	agent.iteration++
+	agent.record(context.Background(), 2, "search_terms.refinement_2", nil, context.DeadlineExceeded)
+
+	result := agent.Result()
+	if strings.Join(result.Terms, "|") != strings.Join(cassetteRefinedTerms, "|") {
+		t.Errorf("terms = %q, want the best (initial) set", result.Terms)
+	}
+	if result.BestIteration != 0 {
+		t.Errorf("best iteration = %d, want 0", result.BestIteration)
+	}
+	if len(result.Trace) != 3 || result.Trace[2].Error == "" {
+		t.Errorf("trace = %+v, want 3 entries ending in the failed call", result.Trace)
+	}
+	if result.Trace[1].Score >= result.Trace[0].Score {
+		t.Errorf("scores %.1f >= %.1f, want the shorter set to score lower", result.Trace[1].Score, result.Trace[0].Score)
+	}
+}
+
+func TestGenerateTracksEveryCandidate(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	backend := &scriptedBackend{steps: []scriptedStep{
+		{terms: cassetteInitialTerms},
+		{terms: cassetteRefinedTerms},
+	}}
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Trace) != 2 || result.Trace[0].Stage != USAGE_STAGE_INITIAL || result.Trace[1].Stage != "search_terms.refinement_1" {
+		t.Fatalf("trace = %+v", result.Trace)
+	}
+	if result.BestIteration != 1 || result.Score != result.Trace[1].Score {
+		t.Errorf("best iteration %d score %.1f, want refinement 1 (%.1f)", result.BestIteration, result.Score, result.Trace[1].Score)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🏷️ SEARCH TERM RECORDS - What Each Term Is For
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// Writers need more than a list: which terms are questions for the FAQ,
+// which ones sell, which format they are about. submit_search_terms asks
+// the model for intent, funnel stage, format and source keyword per term;
+// everything is then checked here:
+//   - pattern families always come from the local rules (patterns.go)
+//   - format mentioned in the term itself beats whatever the model said
+//   - invalid or missing values are inferred locally and listed in Corrected
+//   - the source keyword has to be one of the base keywords
+//
+// Plain-string answers (old schema, repaired responses, offline mode) get
+// the same fields, all inferred.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	INTENT_INFORMATIONAL = "informational"
+	INTENT_NAVIGATIONAL  = "navigational"
+	INTENT_COMMERCIAL    = "commercial"
+	INTENT_TRANSACTIONAL = "transactional"
+
+	FUNNEL_AWARENESS     = "awareness"
+	FUNNEL_CONSIDERATION = "consideration"
+	FUNNEL_CONVERSION    = "conversion"
+
+	FORMAT_AUDIOBOOK = "audiobook"
+	FORMAT_EBOOK     = "ebook"
+	FORMAT_MAGAZINE  = "magazine"
+	FORMAT_ANY       = "any" // No format, or several
+)
+
+var (
+	SEARCH_TERM_INTENTS = []string{INTENT_INFORMATIONAL, INTENT_NAVIGATIONAL, INTENT_COMMERCIAL, INTENT_TRANSACTIONAL}
+	FUNNEL_STAGES       = []string{FUNNEL_AWARENESS, FUNNEL_CONSIDERATION, FUNNEL_CONVERSION}
+	TARGET_FORMATS      = []string{FORMAT_AUDIOBOOK, FORMAT_EBOOK, FORMAT_MAGAZINE, FORMAT_ANY}
+)
+
+// FUNNEL_ALIASES - Other names models use for the stages
+var FUNNEL_ALIASES = map[string]string{
+	"tofu": FUNNEL_AWARENESS, "top": FUNNEL_AWARENESS, "discovery": FUNNEL_AWARENESS,
+	"mofu": FUNNEL_CONSIDERATION, "middle": FUNNEL_CONSIDERATION, "evaluation": FUNNEL_CONSIDERATION,
+	"bofu": FUNNEL_CONVERSION, "bottom": FUNNEL_CONVERSION, "decision": FUNNEL_CONVERSION, "purchase": FUNNEL_CONVERSION,
+}
+
+// INTENT_FUNNEL_STAGES - Stage used when the model gave none (or an invalid one)
+var INTENT_FUNNEL_STAGES = map[string]string{
+	INTENT_INFORMATIONAL: FUNNEL_AWARENESS,
+	INTENT_NAVIGATIONAL:  FUNNEL_CONVERSION,
+	INTENT_COMMERCIAL:    FUNNEL_CONSIDERATION,
+	INTENT_TRANSACTIONAL: FUNNEL_CONVERSION,
+}
+
+// PATTERN_INTENTS - Intent implied by a rule id, strongest first (rule ids are the same in every locale)
+var PATTERN_INTENTS = []struct{ Pattern, Intent string }{
+	{"value_terms", INTENT_TRANSACTIONAL},
+	{"comparisons", INTENT_COMMERCIAL},
+	{"best_lists", INTENT_COMMERCIAL},
+	{"questions", INTENT_INFORMATIONAL},
+}
+
+// NAVIGATIONAL_WORDS - Brands and apps: the searcher wants a specific place
+var NAVIGATIONAL_WORDS = map[string]bool{
+	"nextory": true, "storytel": true, "bookbeat": true, "audible": true, "kindle": true,
+	"spotify": true, "app": true, "login": true,
+}
+
+// FORMAT_WORDS - Word stems naming each format (all built-in locales)
+var FORMAT_WORDS = map[string][]string{
+	FORMAT_AUDIOBOOK: {"audiobook", "audio book", "ljudbok", "ljudböcker", "äänikirj", "hörbuch", "hörbüch", "luisterboek"},
+	FORMAT_EBOOK:     {"ebook", "e-book", "e-bok", "e-böcker", "e-kirj"},
+	FORMAT_MAGAZINE:  {"magazine", "tidning", "lehti", "lehd", "zeitschrift", "tijdschrift"},
+}
+
+// FORMAT_REGEXES - FORMAT_WORDS anchored at the start of a word, so "facebook" names no ebook
+var FORMAT_REGEXES = formatRegexes(FORMAT_WORDS)
+
+func formatRegexes(words map[string][]string) map[string]*regexp.Regexp {
+	regexes := make(map[string]*regexp.Regexp, len(words))
+	for format, stems := range words {
+		quoted := make([]string, len(stems))
+		for i, stem := range stems {
+			quoted[i] = regexp.QuoteMeta(stem)
+		}
+		regexes[format] = regexp.MustCompile(`(?:^|[^\p{L}\p{N}])(?:` + strings.Join(quoted, "|") + `)`)
+	}
+	return regexes
+}
+
+// SearchTermRecord - One search term and how to use it
+type SearchTermRecord struct {
+	Term          string   `json:"term"`
+	Intent        string   `json:"intent"`       // SEARCH_TERM_INTENTS
+	FunnelStage   string   `json:"funnel_stage"` // FUNNEL_STAGES
+	Patterns      []string `json:"patterns"`     // Rule ids the term matches (patterns.go)
+	Format        string   `json:"format"`       // TARGET_FORMATS
+	SourceKeyword string   `json:"source_keyword,omitempty"`
+	Corrected     []string `json:"corrected,omitempty"` // Fields where the model's value was missing or wrong
+}
+
+// buildTermRecords - One record per term; fields are the model's annotations by term (may be nil)
+func buildTermRecords(terms []string, fields map[string]map[string]string, keywords []string, patterns *PatternSet, stopWords map[string]bool) []SearchTermRecord {
+	records := make([]SearchTermRecord, len(terms))
+	for i, term := range terms {
+		records[i] = buildTermRecord(term, fields[term], keywords, patterns, stopWords)
+	}
+	return records
+}
+
+// buildTermRecord - The model's annotations, validated; anything invalid or missing is inferred
+func buildTermRecord(term string, fields map[string]string, keywords []string, patterns *PatternSet, stopWords map[string]bool) SearchTermRecord {
+	lower := strings.ToLower(term)
+	record := SearchTermRecord{Term: term, Patterns: patterns.Matching(lower)}
+	if record.Patterns == nil {
+		record.Patterns = []string{}
+	}
+	annotated := fields != nil
+	correct := func(field string) {
+		if annotated {
+			record.Corrected = append(record.Corrected, field)
+		}
+	}
+
+	record.Intent = enumValue(fields["intent"], SEARCH_TERM_INTENTS, nil)
+	if record.Intent == "" {
+		record.Intent = inferIntent(lower, record.Patterns)
+		correct("intent")
+	}
+
+	record.FunnelStage = enumValue(fields["funnel_stage"], FUNNEL_STAGES, FUNNEL_ALIASES)
+	if record.FunnelStage == "" {
+		record.FunnelStage = INTENT_FUNNEL_STAGES[record.Intent]
+		correct("funnel_stage")
+	}
+
+	claimed := enumValue(fields["format"], TARGET_FORMATS, nil)
+	record.Format = detectFormat(lower)
+	switch {
+	case record.Format == "" && claimed != "":
+		record.Format = claimed
+	case record.Format == "":
+		record.Format = FORMAT_ANY
+		correct("format")
+	case claimed != record.Format:
+		correct("format") // The term itself names another format
+	}
+
+	record.SourceKeyword = matchKeyword(fields["source_keyword"], keywords, stopWords)
+	if record.SourceKeyword == "" {
+		record.SourceKeyword = closestKeyword(term, keywords, stopWords)
+		if fields["source_keyword"] != "" {
+			correct("source_keyword")
+		}
+	}
+	return record
+}
+
+// enumValue - value when it (or its alias) is one of allowed, "" otherwise
+func enumValue(value string, allowed []string, aliases map[string]string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := aliases[value]; ok {
+		value = alias
+	}
+	if containsString(allowed, value) {
+		return value
+	}
+	return ""
+}
+
+// inferIntent - From the matched pattern families, then brand/app words; informational otherwise
+func inferIntent(termLower string, patterns []string) string {
+	for _, p := range PATTERN_INTENTS {
+		if containsString(patterns, p.Pattern) {
+			return p.Intent
+		}
+	}
+	for _, word := range normalizedWords(termLower, nil) { // Stop-words are never brand words
+		if NAVIGATIONAL_WORDS[word] {
+			return INTENT_NAVIGATIONAL
+		}
+	}
+	return INTENT_INFORMATIONAL
+}
+
+// detectFormat - The one format the term names; "" for none, FORMAT_ANY for several
+func detectFormat(termLower string) string {
+	found := ""
+	for _, format := range TARGET_FORMATS {
+		if re, ok := FORMAT_REGEXES[format]; ok && re.MatchString(termLower) {
+			if found != "" {
+				return FORMAT_ANY
+			}
+			found = format
+		}
+	}
+	return found
+}
+
+// matchKeyword - The base keyword claimed matches (same normalised query), or ""
+func matchKeyword(claimed string, keywords []string, stopWords map[string]bool) string {
+	if strings.TrimSpace(claimed) == "" {
+		return ""
+	}
+	claimedBag, _ := termKeys(claimed, stopWords)
+	for _, keyword := range keywords {
+		if bag, _ := termKeys(keyword, stopWords); bag == claimedBag {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// closestKeyword - The base keyword sharing the most words with term (earliest on ties), or ""
+func closestKeyword(term string, keywords []string, stopWords map[string]bool) string {
+	termBag, _ := termKeys(term, stopWords)
+	termWords := make(map[string]bool)
+	for _, word := range strings.Fields(termBag) {
+		termWords[word] = true
+	}
+
+	best, bestShared := "", 0
+	for _, keyword := range keywords {
+		bag, _ := termKeys(keyword, stopWords)
+		shared := 0
+		for _, word := range strings.Fields(bag) {
+			if termWords[word] {
+				shared++
+			}
+		}
+		if shared > bestShared {
+			best, bestShared = keyword, shared
+		}
+	}
+	return best
+}
+
+// termRecordSchema - JSON schema of one submit_search_terms item
+func termRecordSchema() string {
+	enum := func(values []string) string {
+		b, _ := json.Marshal(values)
+		return string(b)
+	}
+	return `{
+						"type": "object",
+						"properties": {
+							"term": {"type": "string", "description": "The search query, exactly as people type it"},
+							"intent": {"type": "string", "enum": ` + enum(SEARCH_TERM_INTENTS) + `},
+							"funnel_stage": {"type": "string", "enum": ` + enum(FUNNEL_STAGES) + `},
+							"format": {"type": "string", "enum": ` + enum(TARGET_FORMATS) + `, "description": "Format the searcher wants (any = none or several)"},
+							"source_keyword": {"type": "string", "description": "The base keyword this term grew from"}
+						},
+						"required": ["term", "intent", "funnel_stage", "format"]
+					}`
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTermRecordsValidateModelAnnotations(t *testing.T) {
+	list, err := repairToolList(ChatResponse{ToolArguments: `{"search_terms": [
+		{"term": "best romance audiobooks", "intent": "commercial", "funnel_stage": "BOFU", "format": "audiobook", "source_keyword": "Romance Audiobooks"},
+		{"term": "free romance ebook trial", "intent": "buying", "funnel_stage": "conversion", "format": "audiobook", "source_keyword": "romance thrillers"},
+		{"term": "where to listen to romance books", "intent": "informational", "funnel_stage": "awareness", "format": "any"}
+	]}`}, "search_terms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]map[string]string)
+	for i, term := range list.Items {
+		fields[term] = list.Fields[i]
+	}
+
+	keywords := []string{"romance audiobooks", "romance ebooks", "romance books"}
+	records := buildTermRecords(list.Items, fields, keywords, defaultPatternSet, defaultStopWordSet)
+
+	best := records[0]
+	if best.Intent != INTENT_COMMERCIAL || best.FunnelStage != FUNNEL_CONVERSION || best.Format != FORMAT_AUDIOBOOK ||
+		best.SourceKeyword != "romance audiobooks" || len(best.Corrected) != 0 || !containsString(best.Patterns, "best_lists") {
+		t.Errorf("valid annotations not kept: %+v", best)
+	}
+
+	// Unknown intent → inferred from value_terms; the term says ebook; the keyword isn't a base keyword
+	free := records[1]
+	if free.Intent != INTENT_TRANSACTIONAL || free.Format != FORMAT_EBOOK || free.SourceKeyword != "romance ebooks" ||
+		strings.Join(free.Corrected, ",") != "intent,format,source_keyword" {
+		t.Errorf("invalid annotations not corrected: %+v", free)
+	}
+
+	if where := records[2]; where.SourceKeyword != "romance books" || len(where.Corrected) != 0 {
+		t.Errorf("missing source keyword not inferred quietly: %+v", where)
+	}
+}
+
+func TestPlainTermsGetInferredRecords(t *testing.T) {
+	records := buildTermRecords(cassetteRefinedTerms, nil, []string{"romance books"}, defaultPatternSet, defaultStopWordSet)
+	byTerm := make(map[string]SearchTermRecord)
+	for _, r := range records {
+		if len(r.Corrected) != 0 {
+			t.Errorf("%q: corrections without annotations: %v", r.Term, r.Corrected)
+		}
+		byTerm[r.Term] = r
+	}
+
+	for term, want := range map[string][3]string{
+		"where to listen to romance books":         {INTENT_INFORMATIONAL, FUNNEL_AWARENESS, FORMAT_ANY},
+		"free romance ebook trial":                 {INTENT_TRANSACTIONAL, FUNNEL_CONVERSION, FORMAT_EBOOK},
+		"romance audiobooks vs ebooks":             {INTENT_COMMERCIAL, FUNNEL_CONSIDERATION, FORMAT_ANY},
+		"which romance app works offline":          {INTENT_INFORMATIONAL, FUNNEL_AWARENESS, FORMAT_ANY},
+		"romance audiobooks for commute":           {INTENT_INFORMATIONAL, FUNNEL_AWARENESS, FORMAT_AUDIOBOOK},
+		"kindle unlimited alternative for romance": {INTENT_TRANSACTIONAL, FUNNEL_CONVERSION, FORMAT_ANY},
+	} {
+		r := byTerm[term]
+		if got := [3]string{r.Intent, r.FunnelStage, r.Format}; got != want {
+			t.Errorf("%q: %v, want %v", term, got, want)
+		}
+	}
+}
+
+func TestDetectFormatMatchesWholeWords(t *testing.T) {
+	for term, want := range map[string]string{
+		"romance ebooks":                FORMAT_EBOOK,
+		"fantasy-hörbuch":               FORMAT_AUDIOBOOK,
+		"romance audiobooks vs e-books": FORMAT_ANY,
+		"äänikirjat romantiikka":        FORMAT_AUDIOBOOK,
+		"facebook romance groups":       "",
+		"free-books romance":            "",
+		"romance books for kids":        "",
+		"webook romance club":           "",
+	} {
+		if got := detectFormat(term); got != want {
+			t.Errorf("%q: format %q, want %q", term, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⏱️ TIMEOUTS - Per-Stage and Per-Call Deadlines
+// ═══════════════════════════════════════════════════════════════════════════
+//
+//   --timeout (whole run)
+//     ├── keywords stage     [timeouts] keywords_ms
+//     └── search term stage  [timeouts] search_terms_ms
+//           └── every call   [timeouts] call_ms (each retry attempt gets a fresh one)
+//
+// Every deadline is derived from its parent, so the tighter one always wins.
+// A slow keyword call can no longer eat the search term stage's time, and a
+// hung refinement call ends that refinement instead of the whole stage.
+// Each stage deadline has to fit one call's full retry chain (retryChainMs),
+// and a keyword stage that still runs out falls back to template keywords.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+// StageTimeouts - [timeouts] in milliseconds; 0 = only the parent deadline applies
+type StageTimeouts struct {
+	KeywordsMs    int `json:"keywords_ms"`
+	SearchTermsMs int `json:"search_terms_ms"`
+	CallMs        int `json:"call_ms"` // Per attempt, inside the retry loop
+}
+
+func defaultStageTimeouts() StageTimeouts {
+	return StageTimeouts{
+		KeywordsMs:    100000, // One call's retry chain (~94s with the default [retry])
+		SearchTermsMs: 240000, // Initial generation plus refinements
+		CallMs:        30000,
+	}
+}
+
+// retryChainMs - Worst case for one call: every attempt hits call_ms, every backoff its full jitter
+func retryChainMs(callMs int, policy RetryPolicy) int {
+	total := float64(callMs * policy.MaxAttempts)
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		base := float64(policy.InitialBackoffMs) * math.Pow(policy.Multiplier, float64(attempt-1))
+		total += math.Min(base, float64(policy.MaxBackoffMs)) * (1 + policy.Jitter)
+	}
+	return int(math.Ceil(total))
+}
+
+// withTimeoutMs - context.WithTimeout, or just a cancel func when ms is 0
+func withTimeoutMs(ctx context.Context, ms int) (context.Context, context.CancelFunc) {
+	if ms <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+}
+
+// StageTimeoutError - Names the stage (usage label) whose time ran out
+type StageTimeoutError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageTimeoutError) Error() string {
+	return fmt.Sprintf("%s ran out of time: %v", e.Stage, e.Err)
+}
+
+func (e *StageTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// asStageTimeout - Wraps deadline errors with the stage name; other errors pass through
+func asStageTimeout(stage string, err error) error {
+	var already *StageTimeoutError
+	if err == nil || errors.As(err, &already) || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &StageTimeoutError{Stage: stage, Err: err}
+}
+
+// timedOutStage - The stage named by a StageTimeoutError anywhere in the chain, "" otherwise
+func timedOutStage(err error) string {
+	var timeoutErr *StageTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.Stage
+	}
+	return ""
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ⌛ CALL TIMEOUT BACKEND
+// ═══════════════════════════════════════════════════════════════════════════
+
+// CallTimeoutBackend - Gives every call its own deadline under the caller's context
+type CallTimeoutBackend struct {
+	inner   LLMBackend
+	timeout time.Duration
+}
+
+// NewCallTimeoutBackend wraps inner; ms <= 0 disables the per-call deadline
+func NewCallTimeoutBackend(inner LLMBackend, ms int) LLMBackend {
+	if inner == nil || ms <= 0 {
+		return inner
+	}
+	return &CallTimeoutBackend{inner: inner, timeout: time.Duration(ms) * time.Millisecond}
+}
+
+func (b *CallTimeoutBackend) Name() string {
+	return b.inner.Name()
+}
+
+func (b *CallTimeoutBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	callCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	resp, err := b.inner.ChatWithTools(callCtx, req)
+	if err != nil && ctx.Err() == nil && callCtx.Err() != nil {
+		// Only this call's deadline fired: say so (and stay retryable)
+		return resp, fmt.Errorf("call timed out after %s: %w", b.timeout, context.DeadlineExceeded)
+	}
+	return resp, err
+}
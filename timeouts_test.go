@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stallingBackend - Answers the first `answers` calls, then hangs until the caller gives up
+type stallingBackend struct {
+	scriptedBackend
+	answers int
+}
+
+func (b *stallingBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	if b.answers > 0 {
+		b.answers--
+		return b.scriptedBackend.ChatWithTools(ctx, req)
+	}
+	<-ctx.Done()
+	return ChatResponse{}, ctx.Err()
+}
+
+func TestRefinementCallTimeoutKeepsBestSoFar(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.Retry.MaxAttempts = 1
+	cfg.Timeouts.CallMs = 20
+
+	backend := &stallingBackend{scriptedBackend: scriptedBackend{steps: []scriptedStep{{terms: cassetteInitialTerms}}}, answers: 1}
+	result, err := runSearchTermStage(context.Background(), backend, "romance books", []string{"romance books"}, cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("a refinement timeout must not fail the stage: %v", err)
+	}
+
+	if result.Status != SEARCH_TERM_STATUS_TIMED_OUT || result.TimedOutStage != "search_terms.refinement_1" {
+		t.Errorf("status %q / stage %q, want timed_out in search_terms.refinement_1", result.Status, result.TimedOutStage)
+	}
+	if strings.Join(result.Terms, "|") != strings.Join(cassetteInitialTerms, "|") {
+		t.Errorf("terms = %q, want the initial set", result.Terms)
+	}
+}
+
+func TestKeywordStageTimeoutFallsBackToTemplateKeywords(t *testing.T) {
+	cfg := cassetteTestConfig(t)
+	cfg.Retry.MaxAttempts = 1
+	cfg.Timeouts.KeywordsMs = 20
+	cfg.Timeouts.CallMs = 0
+
+	// Overall context has plenty of time left: only the keyword stage's own deadline fires
+	result, err := runKeywordStage(context.Background(), &stallingBackend{}, "romance books", cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("a keyword stage timeout must not fail the run: %v", err)
+	}
+	want := generateFallbackKeywords("romance books", cfg.Keywords.Count, localeFor(cfg.Locale))
+	if strings.Join(result.Keywords, "|") != strings.Join(want, "|") || result.Status != KEYWORD_STATUS_TIMED_OUT || result.TimedOutStage != USAGE_STAGE_KEYWORDS {
+		t.Errorf("result = %+v, want the fallback keywords timed out in %q", result, USAGE_STAGE_KEYWORDS)
+	}
+
+	// The whole run out of time: nothing left to continue with
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	cfg.Timeouts.KeywordsMs = 0
+	if _, err := runPipeline(ctx, &stallingBackend{}, "romance books", cfg); err == nil {
+		t.Error("expected the run to fail when its own deadline fires")
+	}
+}
+
+func TestStageTimeoutsMustFitTheRetryChain(t *testing.T) {
+	if chain := retryChainMs(30000, defaultRetryPolicy()); chain != 93600 {
+		t.Errorf("retry chain = %dms, want 3 × 30000 + 1200 + 2400", chain)
+	}
+	if err := defaultConfig().Validate(); err != nil {
+		t.Errorf("defaults must validate: %v", err)
+	}
+
+	cfg := defaultConfig()
+	cfg.Timeouts.KeywordsMs = 45000
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "timeouts.keywords_ms must fit") {
+		t.Errorf("err = %v, want keywords_ms rejected", err)
+	}
+}
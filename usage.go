@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 💰 USAGE & COST ACCOUNTING - Where Did the Tokens Go?
+// ═══════════════════════════════════════════════════════════════════════════
+//
+// MeteredBackend records the Usage of every successful call under a stage
+// label (keywords, search_terms.initial, search_terms.refinement_1, ...).
+// Cost comes from the model registry's price table; models without a price
+// fall back to the cost the API reported (OpenRouter sends one).
+//
+// The agent labels its calls via the context, so one tracker sees the whole run.
+//
+// ═══════════════════════════════════════════════════════════════════════════
+
+const (
+	USAGE_STAGE_KEYWORDS                   = "keywords"
+	USAGE_STAGE_KEYWORD_REFINEMENT_PATTERN = "keywords.refinement_%d"
+	USAGE_STAGE_SEARCH_TERMS               = "search_terms" // The stage as a whole (timeouts)
+	USAGE_STAGE_INITIAL                    = "search_terms.initial"
+	USAGE_STAGE_REFINEMENT_PATTERN         = "search_terms.refinement_%d"
+	USAGE_STAGE_TOP_UP_SUFFIX              = ".top_up" // Appended to the stage whose response came back short
+	USAGE_STAGE_CLUSTERS                   = "clusters"
+)
+
+// TokenUsage - Per-call usage as reported by the API
+type TokenUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	ReportedCostUSD  float64 `json:"reported_cost_usd,omitempty"`
+}
+
+// StageUsage - Aggregated usage for one stage label
+type StageUsage struct {
+	Stage            string   `json:"stage"`
+	Models           []string `json:"models,omitempty"`
+	Calls            int      `json:"calls"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	CostUSD          float64  `json:"cost_usd"`
+}
+
+// UsageReport - Per-stage breakdown plus the grand total
+type UsageReport struct {
+	Stages []StageUsage `json:"stages"`
+	Total  StageUsage   `json:"total"`
+}
+
+type usageStageKey struct{}
+
+// withUsageStage - Labels every call made with this context
+func withUsageStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, usageStageKey{}, stage)
+}
+
+func usageStageFrom(ctx context.Context, fallback string) string {
+	if stage, ok := ctx.Value(usageStageKey{}).(string); ok {
+		return stage
+	}
+	return fallback
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🧮 TRACKER
+// ═══════════════════════════════════════════════════════════════════════════
+
+// UsageTracker - Thread-safe accumulator for one run
+type UsageTracker struct {
+	registry *ModelRegistry
+	mu       sync.Mutex
+	stages   map[string]*StageUsage
+	order    []string
+}
+
+func NewUsageTracker(registry *ModelRegistry) *UsageTracker {
+	return &UsageTracker{registry: registry, stages: make(map[string]*StageUsage)}
+}
+
+// Record - Adds one call's usage; returns the cost it was charged
+func (t *UsageTracker) Record(stage, model string, usage TokenUsage) float64 {
+	cost := t.price(model, usage)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.stages[stage]
+	if !ok {
+		s = &StageUsage{Stage: stage}
+		t.stages[stage] = s
+		t.order = append(t.order, stage)
+	}
+	if !containsString(s.Models, model) {
+		s.Models = append(s.Models, model)
+	}
+	s.Calls++
+	s.PromptTokens += usage.PromptTokens
+	s.CompletionTokens += usage.CompletionTokens
+	s.TotalTokens += usage.TotalTokens
+	s.CostUSD += cost
+	return cost
+}
+
+func (t *UsageTracker) price(model string, usage TokenUsage) float64 {
+	if t.registry != nil {
+		if spec, err := t.registry.Lookup(model); err == nil && (spec.PromptCostPerToken > 0 || spec.CompletionCostPerToken > 0) {
+			return float64(usage.PromptTokens)*spec.PromptCostPerToken +
+				float64(usage.CompletionTokens)*spec.CompletionCostPerToken
+		}
+	}
+	return usage.ReportedCostUSD
+}
+
+// Report - Snapshot in first-seen stage order
+func (t *UsageTracker) Report() UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := UsageReport{Stages: []StageUsage{}, Total: StageUsage{Stage: "total"}}
+	for _, name := range t.order {
+		s := *t.stages[name]
+		s.Models = append([]string(nil), s.Models...)
+		report.Stages = append(report.Stages, s)
+		report.Total.add(s)
+	}
+	return report
+}
+
+func (s *StageUsage) add(other StageUsage) {
+	for _, m := range other.Models {
+		if !containsString(s.Models, m) {
+			s.Models = append(s.Models, m)
+		}
+	}
+	s.Calls += other.Calls
+	s.PromptTokens += other.PromptTokens
+	s.CompletionTokens += other.CompletionTokens
+	s.TotalTokens += other.TotalTokens
+	s.CostUSD += other.CostUSD
+}
+
+// mergeUsageReports - Batch totals: stages with the same label are summed
+func mergeUsageReports(reports []UsageReport) UsageReport {
+	merged := UsageReport{Stages: []StageUsage{}, Total: StageUsage{Stage: "total"}} // Same shape as Report, even for no reports
+	index := make(map[string]int)
+	for _, r := range reports {
+		for _, s := range r.Stages {
+			i, ok := index[s.Stage]
+			if !ok {
+				i = len(merged.Stages)
+				index[s.Stage] = i
+				merged.Stages = append(merged.Stages, StageUsage{Stage: s.Stage})
+			}
+			merged.Stages[i].add(s)
+		}
+		merged.Total.add(r.Total)
+	}
+	return merged
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 📟 METERED BACKEND
+// ═══════════════════════════════════════════════════════════════════════════
+
+// MeteredBackend - Records usage of every successful call into a tracker
+type MeteredBackend struct {
+	inner        LLMBackend
+	tracker      *UsageTracker
+	defaultStage string
+}
+
+// NewMeteredBackend wraps inner; nil backend or tracker disables metering
+func NewMeteredBackend(inner LLMBackend, tracker *UsageTracker, defaultStage string) LLMBackend {
+	if inner == nil || tracker == nil {
+		return inner
+	}
+	return &MeteredBackend{inner: inner, tracker: tracker, defaultStage: defaultStage}
+}
+
+func (b *MeteredBackend) Name() string {
+	return b.inner.Name()
+}
+
+func (b *MeteredBackend) ChatWithTools(ctx context.Context, req ChatRequest) (ChatResponse, error) {
+	resp, err := b.inner.ChatWithTools(ctx, req)
+	if err == nil && resp.Usage != nil {
+		b.tracker.Record(usageStageFrom(ctx, b.defaultStage), req.Model, *resp.Usage)
+	}
+	return resp, err
+}
+
+// ═══════════════════════════════════════════════════════════════════════════
+// 🖨️ REPORTING
+// ═══════════════════════════════════════════════════════════════════════════
+
+func printUsageReport(report UsageReport) {
+	if len(report.Stages) == 0 {
+		return
+	}
+	fmt.Fprintln(statusOut, "\n💰 Token Usage & Cost:")
+	fmt.Fprintln(statusOut, strings.Repeat("─", 78))
+	fmt.Fprintf(statusOut, "  %-28s %5s %10s %12s %10s\n", "Stage", "Calls", "Prompt", "Completion", "Cost")
+	for _, s := range report.Stages {
+		fmt.Fprintf(statusOut, "  %-28s %5d %10d %12d %10s\n", s.Stage, s.Calls, s.PromptTokens, s.CompletionTokens, formatUSD(s.CostUSD))
+	}
+	fmt.Fprintln(statusOut, strings.Repeat("─", 78))
+	t := report.Total
+	fmt.Fprintf(statusOut, "  %-28s %5d %10d %12d %10s\n", "TOTAL", t.Calls, t.PromptTokens, t.CompletionTokens, formatUSD(t.CostUSD))
+}
+
+func formatUSD(v float64) string {
+	return fmt.Sprintf("$%.4f", v)
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMergeUsageReports(t *testing.T) {
+	record := func(calls ...[3]string) UsageReport {
+		tracker := NewUsageTracker(nil)
+		for _, c := range calls {
+			tracker.Record(c[0], c[1], TokenUsage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, ReportedCostUSD: 0.01})
+		}
+		return tracker.Report()
+	}
+	first := record([3]string{USAGE_STAGE_KEYWORDS, "model-a"}, [3]string{USAGE_STAGE_INITIAL, "model-a"})
+	second := record([3]string{USAGE_STAGE_INITIAL, "model-b"}, [3]string{USAGE_STAGE_INITIAL, "model-b"}, [3]string{"search_terms.refinement_1", "model-b"})
+
+	for _, tc := range []struct {
+		name    string
+		reports []UsageReport
+		stages  string // stage:calls:models, in first-seen order
+		calls   int
+		cost    float64
+	}{
+		{"no reports", nil, "", 0, 0},
+		{"one report", []UsageReport{first}, "keywords:1:model-a|search_terms.initial:1:model-a", 2, 0.02},
+		{"same stage summed", []UsageReport{first, second, {}},
+			"keywords:1:model-a|search_terms.initial:3:model-a;model-b|search_terms.refinement_1:1:model-b", 5, 0.05},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := mergeUsageReports(tc.reports)
+			var stages []string
+			for _, s := range merged.Stages {
+				stages = append(stages, strings.Join([]string{s.Stage, strconv.Itoa(s.Calls), strings.Join(s.Models, ";")}, ":"))
+			}
+			if strings.Join(stages, "|") != tc.stages {
+				t.Errorf("stages = %q, want %q", strings.Join(stages, "|"), tc.stages)
+			}
+			if merged.Total.Calls != tc.calls || merged.Total.TotalTokens != 150*tc.calls || math.Abs(merged.Total.CostUSD-tc.cost) > 1e-9 {
+				t.Errorf("total = %+v, want %d calls costing %g", merged.Total, tc.calls, tc.cost)
+			}
+			if data, _ := json.Marshal(merged); !strings.Contains(string(data), `"stages":[`) {
+				t.Errorf("stages must encode as a list: %s", data)
+			}
+		})
+	}
+
+	// Merging copies: later changes to a source report don't leak into the batch total
+	merged := mergeUsageReports([]UsageReport{first})
+	first.Stages[0].Models[0] = "changed"
+	if merged.Stages[0].Models[0] != "model-a" {
+		t.Error("merged report shares its model lists with the source")
+	}
+}